@@ -0,0 +1,301 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting of the API. Each setting has a default, can be set in a YAML file
+// and is overridden by its environment variable, also when given in a .env file.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	LLM      LLMConfig      `yaml:"llm"`
+	Email    EmailConfig    `yaml:"email"`
+	App      AppConfig      `yaml:"app"`
+	Clinic   ClinicConfig   `yaml:"clinic"`
+	Catalogs CatalogsConfig `yaml:"catalogs"`
+	HL7      HL7Config      `yaml:"hl7"`
+	Health   HealthConfig   `yaml:"health"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Logging  LoggingConfig  `yaml:"logging"`
+}
+
+type ServerConfig struct {
+	Address           string        `yaml:"address" env:"LISTEN_ADDRESS" default:":8080" validate:"required"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" default:"10s" validate:"min=0"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" default:"30s" validate:"min=0"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" default:"2m" validate:"min=0"` // a chat reply waits for Gemini
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" default:"2m" validate:"min=0"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s" validate:"min=0"`
+	TLSCertFile       string        `yaml:"tls_cert_file" env:"TLS_CERT_FILE" validate:"required_with=TLSKeyFile,omitempty,file"` // HTTPS when both files are set
+	TLSKeyFile        string        `yaml:"tls_key_file" env:"TLS_KEY_FILE" validate:"required_with=TLSCertFile,omitempty,file"`
+}
+
+type DatabaseConfig struct {
+	Host           string `yaml:"host" env:"DB_HOST" validate:"required"`
+	Port           int    `yaml:"port" env:"DB_PORT" default:"5432" validate:"min=1,max=65535"`
+	User           string `yaml:"user" env:"DB_USER" validate:"required"`
+	Password       string `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name           string `yaml:"name" env:"DB_NAME" validate:"required"`
+	SSLMode        string `yaml:"sslmode" env:"DB_SSLMODE" default:"disable" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	TimeZone       string `yaml:"timezone" env:"DB_TIMEZONE" default:"Asia/Jakarta" validate:"timezone"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"MIGRATE_ON_START" default:"true"`
+}
+
+type LLMConfig struct {
+	APIKey string `yaml:"api_key" env:"GEMINI_API_KEY" validate:"required" secret:"true"`
+	Model  string `yaml:"model" env:"GEMINI_MODEL" default:"gemini-2.0-flash" validate:"required"`
+}
+
+type EmailConfig struct {
+	ServiceURL string `yaml:"service_url" env:"EMAIL_SERVICE_URL" default:"http://52.230.88.220:16250/send-email" validate:"required,url"`
+	Token      string `yaml:"token" env:"EMAIL_TOKEN" secret:"true"`
+	OTPToken   string `yaml:"otp_token" env:"EMAIL_OTP_TOKEN" secret:"true"`
+}
+
+type AppConfig struct {
+	WebAppURL         string `yaml:"web_app_url" env:"WEB_APP_URL" default:"https://triana.sportsnow.app" validate:"required,url"`
+	APIBaseURL        string `yaml:"api_base_url" env:"API_BASE_URL" default:"http://localhost:8080" validate:"required,url"`
+	LinkSigningSecret string `yaml:"link_signing_secret" env:"LINK_SIGNING_SECRET" secret:"true"` // links in emails are rejected while empty
+}
+
+type ClinicConfig struct {
+	ClosingTime       string        `yaml:"closing_time" env:"CLINIC_CLOSING_TIME" default:"17:00" validate:"datetime=15:04"`
+	NoShowGracePeriod time.Duration `yaml:"no_show_grace_period" env:"NO_SHOW_GRACE_PERIOD" default:"1h" validate:"min=0"`
+}
+
+type CatalogsConfig struct {
+	ICD10CSVPath string `yaml:"icd10_csv_path" env:"ICD10_CSV_PATH" default:"data/icd10.csv" validate:"required"`
+	DrugsCSVPath string `yaml:"drugs_csv_path" env:"DRUGS_CSV_PATH" default:"data/drugs.csv" validate:"required"`
+}
+
+type HL7Config struct {
+	MLLPAddress          string `yaml:"mllp_address" env:"HL7_MLLP_ADDRESS" validate:"omitempty,hostname_port"` // HL7 is disabled while empty
+	ReceivingApplication string `yaml:"receiving_application" env:"HL7_RECEIVING_APPLICATION" default:"HIS" validate:"required"`
+	ReceivingFacility    string `yaml:"receiving_facility" env:"HL7_RECEIVING_FACILITY" default:"HOSPITAL" validate:"required"`
+}
+
+type HealthConfig struct {
+	CacheTTL     time.Duration `yaml:"cache_ttl" env:"HEALTH_CACHE_TTL" default:"15s" validate:"min=0"` // probes within the TTL reuse the last result
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"3s" validate:"gt=0"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none" validate:"oneof=none otlp stdout"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" default:"http://localhost:4318" validate:"required,url"` // OTLP over HTTP, e.g. a local collector
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" default:"triana-api" validate:"required"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1" validate:"min=0,max=1"` // share of new traces recorded
+}
+
+type LoggingConfig struct {
+	Level     string `yaml:"level" env:"LOG_LEVEL" default:"info" validate:"oneof=debug info warn error"`
+	Format    string `yaml:"format" env:"LOG_FORMAT" default:"json" validate:"oneof=json text"`
+	DebugMode bool   `yaml:"debug_mode" env:"LOG_DEBUG_MODE"` // logs patient data unmasked, never in production
+}
+
+// redacted replaces secrets when the configuration is printed
+const redacted = "********"
+
+// Defaults returns the configuration with only the default values set
+func Defaults() *Config {
+	cfg := &Config{}
+	if err := walk(reflect.ValueOf(cfg).Elem(), func(field reflect.Value, tag reflect.StructTag) error {
+		if value, ok := tag.Lookup("default"); ok {
+			return setField(field, value)
+		}
+		return nil
+	}); err != nil {
+		panic(fmt.Sprintf("invalid default in Config: %v", err)) // the defaults are part of the code
+	}
+	return cfg
+}
+
+// Load reads the configuration from the defaults, the YAML file in CONFIG_FILE (config.yaml when it exists),
+// the .env file and the environment, in increasing precedence, and validates the given sections, all when none
+// is given, e.g. Load("Database") for a command that only uses the database
+func Load(sections ...string) (*Config, error) {
+	cfg := Defaults()
+
+	// variables already set in the environment win over the .env file
+	if err := godotenv.Load(); err == nil {
+		slog.Info("Loaded .env file")
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error loading .env file: %w", err)
+	}
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = "config.yaml"
+	}
+	content, err := os.ReadFile(path)
+	if err == nil {
+		if err := yaml.Unmarshal(content, cfg); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+		slog.Info("Loaded configuration file", "path", path)
+	} else if explicit || !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error reading configuration file: %w", err)
+	}
+
+	// empty variables, e.g. HL7_MLLP_ADDRESS="" in .env, keep the value from the file or the default
+	err = walk(reflect.ValueOf(cfg).Elem(), func(field reflect.Value, tag reflect.StructTag) error {
+		name := tag.Get("env")
+		if value := os.Getenv(name); name != "" && value != "" {
+			if err := setField(field, value); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(sections...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate reports every invalid setting of the given sections by its environment variable, all sections
+// are checked when none is given
+func (c *Config) Validate(sections ...string) error {
+	err := validator.New().Struct(c)
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	var problems []error
+	for _, fieldError := range validationErrors {
+		if !inSections(fieldError.StructNamespace(), sections) {
+			continue
+		}
+		name := envName(c, fieldError.StructNamespace())
+		if fieldError.Tag() == "required" {
+			problems = append(problems, fmt.Errorf("%s is required", name))
+		} else {
+			problems = append(problems, fmt.Errorf("%s is invalid (%s)", name, fieldError.Tag()))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %w", errors.Join(problems...))
+}
+
+// inSections tells whether a validator namespace such as Config.Database.Host belongs to one of the sections
+func inSections(namespace string, sections []string) bool {
+	if len(sections) == 0 {
+		return true
+	}
+	for _, section := range sections {
+		if strings.HasPrefix(namespace, "Config."+section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of the configuration with the secrets masked
+func (c Config) Redacted() Config {
+	walk(reflect.ValueOf(&c).Elem(), func(field reflect.Value, tag reflect.StructTag) error {
+		if tag.Get("secret") == "true" && !field.IsZero() {
+			field.SetString(redacted)
+		}
+		return nil
+	})
+	return c
+}
+
+// String prints the configuration as YAML without its secrets
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// walk calls fn for every setting of the nested config structs
+func walk(v reflect.Value, fn func(field reflect.Value, tag reflect.StructTag) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		structField := v.Type().Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := walk(field, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(field, structField.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// envName maps a validator namespace such as Config.Database.Host to its variable DB_HOST
+func envName(c *Config, namespace string) string {
+	v := reflect.ValueOf(c).Elem()
+	var tag reflect.StructTag
+	for _, name := range strings.Split(namespace, ".")[1:] {
+		structField, ok := v.Type().FieldByName(name)
+		if !ok {
+			return namespace
+		}
+		v = v.FieldByName(name)
+		tag = structField.Tag
+	}
+	if name := tag.Get("env"); name != "" {
+		return name
+	}
+	return namespace
+}
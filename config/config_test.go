@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setRequired sets the settings without a default
+func setRequired(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_NAME", "triana")
+	t.Setenv("GEMINI_API_KEY", "gemini-secret")
+}
+
+// inTempDir keeps the repository's .env and config.yaml out of the test
+func inTempDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestLoadDefaultsAndEnvironment(t *testing.T) {
+	inTempDir(t)
+	setRequired(t)
+	t.Setenv("NO_SHOW_GRACE_PERIOD", "30m")
+	t.Setenv("MIGRATE_ON_START", "false")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Server.Address != ":8080" || cfg.Database.Port != 5432 || cfg.LLM.Model != "gemini-2.0-flash" {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+	if cfg.Database.Host != "localhost" || cfg.Clinic.NoShowGracePeriod != 30*time.Minute || cfg.Database.MigrateOnStart {
+		t.Errorf("environment not applied: %+v", cfg)
+	}
+}
+
+func TestLoadYAMLFileBelowEnvironment(t *testing.T) {
+	dir := inTempDir(t)
+	setRequired(t)
+	t.Setenv("GEMINI_MODEL", "gemini-2.5-flash")
+
+	yaml := "server:\n  address: \":9090\"\nllm:\n  model: gemini-from-file\nhl7:\n  mllp_address: his.local:2575\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Address != ":9090" || cfg.HL7.MLLPAddress != "his.local:2575" {
+		t.Errorf("file settings not applied: %+v", cfg)
+	}
+	if cfg.LLM.Model != "gemini-2.5-flash" {
+		t.Errorf("model = %q, want the environment to win over the file", cfg.LLM.Model)
+	}
+}
+
+func TestLoadReportsInvalidSettingsByVariable(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("CLINIC_CLOSING_TIME", "5pm")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Load accepted a configuration without the required keys")
+	}
+	for _, name := range []string{"DB_USER", "DB_NAME", "GEMINI_API_KEY", "CLINIC_CLOSING_TIME"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q does not mention %s", err, name)
+		}
+	}
+
+	t.Setenv("DB_PORT", "postgres")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Errorf("error = %v, want the unparsable DB_PORT", err)
+	}
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	cfg := Defaults()
+	cfg.Database.Password = "db-password"
+	cfg.LLM.APIKey = "gemini-secret"
+	cfg.Database.Host = "db.internal"
+
+	out := cfg.String()
+	if strings.Contains(out, "db-password") || strings.Contains(out, "gemini-secret") {
+		t.Errorf("secrets printed:\n%s", out)
+	}
+	if !strings.Contains(out, "db.internal") || !strings.Contains(out, redacted) {
+		t.Errorf("expected the host and masked secrets:\n%s", out)
+	}
+	if cfg.LLM.APIKey != "gemini-secret" {
+		t.Error("printing the configuration changed the secret")
+	}
+}
+
+func TestLoadValidatesOnlyTheGivenSections(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_NAME", "triana")
+
+	if _, err := Load("Database"); err != nil {
+		t.Errorf("Load(Database) = %v, want the missing GEMINI_API_KEY ignored", err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "GEMINI_API_KEY") {
+		t.Errorf("Load() = %v, want the missing GEMINI_API_KEY", err)
+	}
+
+	t.Setenv("DB_SSLMODE", "sometimes")
+	if _, err := Load("Database"); err == nil || !strings.Contains(err.Error(), "DB_SSLMODE") {
+		t.Errorf("Load(Database) = %v, want the invalid DB_SSLMODE", err)
+	}
+}
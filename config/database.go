@@ -2,54 +2,46 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-
-	"github.com/BeeCodingAI/triana-api/models"
 )
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	// get database connection string from .env file
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-
+func ConnectDatabase(cfg DatabaseConfig) {
 	// construct DSN
 	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Jakarta",
-		dbHost, dbPort, dbUser, dbPassword, dbName,
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode, cfg.TimeZone,
 	)
 
 	// open connection
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true, // report unique violations as gorm.ErrDuplicatedKey
+	})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Connected to database successfully")
+	slog.Info("Connected to database", "host", cfg.Host, "database", cfg.Name)
 
-	// migrate models to databbase
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.Session{},
-		&models.Queue{},
-		&models.Doctor{},
-		&models.Message{},
-	)
+	// set db to global variable
+	DB = db
+}
 
-	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+// CloseDatabase closes the connection pool once the server stopped using it
+func CloseDatabase() error {
+	if DB == nil {
+		return nil
 	}
 
-	log.Println("Database migrated successfully")
-
-	// set db to global variable
-	DB = db
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/BeeCodingAI/triana-api/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (ctl *Controller) BookAppointment(c *gin.Context) {
+	session_id := c.Param("id")
+
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), session_id)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	var input schemas.BookAppointmentInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	appointment, err := services.BookAppointment(c.Request.Context(), session_id, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	ctl.sendAppointmentNotification(c.Request.Context(), session.User.Email, appointment)
+
+	c.JSON(200, gin.H{"message": "Appointment booked successfully", "appointment": appointment})
+}
+
+func (ctl *Controller) CheckInAppointment(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_appointment_id", "Invalid appointment ID"))
+		return
+	}
+
+	if services.GetAppointmentByID(c.Request.Context(), appointmentID) == nil {
+		abort(c, services.ErrAppointmentNotFound)
+		return
+	}
+
+	queue, err := ctl.queues.CheckInAppointment(c.Request.Context(), appointmentID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// currentQueue is nil if nobody is waiting
+	currentQueue, _ := ctl.queues.GetCurrentQueue(c.Request.Context(), queue.DoctorID)
+
+	c.JSON(200, gin.H{
+		"message":       "Checked in successfully",
+		"queue":         queue,
+		"current_queue": currentQueue,
+	})
+}
+
+func (ctl *Controller) GetAppointmentCalendar(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_appointment_id", "Invalid appointment ID"))
+		return
+	}
+
+	ics, err := services.GenerateAppointmentCalendar(c.Request.Context(), appointmentID)
+	if err != nil {
+		abort(c, services.ErrAppointmentNotFound)
+		return
+	}
+
+	// serve the invitation as a downloadable .ics file
+	c.Header("Content-Disposition", `attachment; filename="appointment.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", []byte(ics))
+}
+
+// sendAppointmentNotification emails the booked appointment with its calendar invitation, failures are only logged
+func (ctl *Controller) sendAppointmentNotification(ctx context.Context, to string, appointment *models.Appointment) {
+	var attachments []schemas.EmailAttachment
+	ics, err := services.GenerateAppointmentCalendar(ctx, appointment.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating calendar invitation", "appointment_id", appointment.ID, "error", err)
+	} else {
+		attachments = append(attachments, services.NewCalendarAttachment(ics))
+	}
+
+	_, err = services.SendAppointmentEmail(ctx, to, *appointment, ctl.cfg.Email.Token, attachments)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending appointment email", "appointment_id", appointment.ID, "error", err)
+	}
+}
+
+func (ctl *Controller) CancelAppointment(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_appointment_id", "Invalid appointment ID"))
+		return
+	}
+
+	// the link in the appointment email carries the token
+	if !utils.VerifyToken("appointment/cancel", appointmentID.String(), c.Query("token")) {
+		abort(c, services.ErrInvalidToken)
+		return
+	}
+
+	if services.GetAppointmentByID(c.Request.Context(), appointmentID) == nil {
+		abort(c, services.ErrAppointmentNotFound)
+		return
+	}
+
+	appointment, err := services.CancelAppointment(c.Request.Context(), appointmentID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":        "Appointment cancelled successfully",
+		"appointment_id": appointment.ID,
+		"status":         appointment.Status,
+	})
+}
@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/services"
+)
+
+// Controller holds the services the handlers depend on, main wires them in through NewController
+type Controller struct {
+	cfg      *config.Config
+	users    *services.UserService
+	sessions *services.SessionService
+	queues   *services.QueueService
+	doctors  *services.DoctorService
+	health   *services.HealthService
+}
+
+func NewController(cfg *config.Config, users *services.UserService, sessions *services.SessionService, queues *services.QueueService, doctors *services.DoctorService, health *services.HealthService) *Controller {
+	return &Controller{cfg: cfg, users: users, sessions: sessions, queues: queues, doctors: doctors, health: health}
+}
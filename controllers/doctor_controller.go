@@ -1,56 +1,90 @@
 package controllers
 
 import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
 	"github.com/BeeCodingAI/triana-api/services"
-	"github.com/BeeCodingAI/triana-api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func DoctorDiagnose(c *gin.Context) {
+func (ctl *Controller) DoctorDiagnose(c *gin.Context) {
 	sessionId := c.Param("id")
 
 	// Parse the diagnosis from the request body
-	var input struct {
-		Diagnosis string `json:"diagnosis" validate:"required"`
+	var input schemas.DoctorDiagnosisInput
+
+	if !bindAndValidate(c, &input) {
+		return
 	}
 
-	if valid, _ := utils.BindAndValidate(c, &input); !valid {
-		return // The response has already been sent in the utility function
+	// Check the ICD-10 codes before saving anything
+	if err := services.ValidateICD10Codes(input.ICD10Codes); err != nil {
+		abort(c, err)
+		return
 	}
 
 	// Call the service to save the diagnosis
-	if err := services.DoctorDiagnose(sessionId, input.Diagnosis); err != nil {
-		c.JSON(500, gin.H{"message": err.Error()})
+	if err := ctl.sessions.DoctorDiagnose(c.Request.Context(), sessionId, input.Diagnosis); err != nil {
+		abort(c, err)
 		return
 	}
 
+	// Save the ICD-10 codes of the diagnosis when given
+	sessionUUID, _ := uuid.Parse(sessionId)
+	if len(input.ICD10Codes) > 0 {
+		if err := services.SaveDoctorDiagnosisCodes(sessionUUID, input.ICD10Codes); err != nil {
+			abort(c, err)
+			return
+		}
+	}
+
+	// chronic diagnoses are kept on the patient's profile
+	if err := services.RecordChronicDiagnosisCodes(sessionUUID, input.ICD10Codes); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error recording chronic conditions", "session_id", sessionUUID, "error", err)
+	}
+
+	evaluatePrediagnosis(c.Request.Context(), sessionUUID, nil, input.AIRating)
+	ctl.sessions.NotifyHL7SessionUpdated(c.Request.Context(), sessionUUID)
+
 	c.JSON(200, gin.H{"message": "Diagnosis saved successfully"})
 }
 
-func GetDoctorDetails(c *gin.Context) {
+func (ctl *Controller) GetDoctorDetails(c *gin.Context) {
 	doctorID := c.Param("id")
 
 	// Parse doctorID to UUID
 	id, err := uuid.Parse(doctorID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid doctor ID"})
+		abort(c, services.Validation("invalid_doctor_id", "Invalid doctor ID"))
 		return
 	}
 
 	// Fetch doctor details from the database
-	doctor := services.GetDoctorByID(doctorID)
+	doctor := ctl.doctors.GetDoctorByID(c.Request.Context(), doctorID)
 	if doctor == nil {
-		c.JSON(404, gin.H{"error": "Doctor not found"})
+		abort(c, services.ErrDoctorNotFound)
 		return
 	}
 
 	// Fetch appointment counts and current queue
-	totalAppointments := services.GetTotalAppointments(id)
-	dailyAppointments := services.GetDailyAppointments(id)
+	totalAppointments := ctl.queues.GetTotalAppointments(c.Request.Context(), id)
+	dailyAppointments := ctl.queues.GetDailyAppointments(c.Request.Context(), id)
 
-	// If empty queue, just let currentQueue be nil
-	currentQueue, err := services.GetCurrentQueue(id)
+	// If empty queue, just let currentQueue and the patient's profile be nil
+	currentQueue, _ := ctl.queues.GetCurrentQueue(c.Request.Context(), id)
+	var patientProfile *models.PatientProfile
+	var intakeSummary *schemas.IntakeSummary
+	var handoffSummary *models.HandoffSummary
+	if currentQueue != nil {
+		patientProfile = services.GetPatientProfile(currentQueue.Session.UserID)
+		intakeSummary = services.GetIntakeSummary(currentQueue.SessionID)
+		handoffSummary = services.GetHandoffSummary(c.Request.Context(), currentQueue.SessionID)
+	}
 
 	// Respond with aggregated data
 	c.JSON(200, gin.H{
@@ -58,5 +92,132 @@ func GetDoctorDetails(c *gin.Context) {
 		"appointment_count_all_time": totalAppointments,
 		"appointment_count_daily":    dailyAppointments,
 		"current_queue":              currentQueue, // Current queue ID
+		"patient_profile":            patientProfile,
+		"intake_summary":             intakeSummary,
+		"handoff_summary":            handoffSummary,
 	})
 }
+
+func (ctl *Controller) GetDoctorSchedules(c *gin.Context) {
+	// Parse doctorID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_doctor_id", "Invalid doctor ID"))
+		return
+	}
+
+	c.JSON(200, gin.H{"schedules": services.GetDoctorSchedules(c.Request.Context(), id)})
+}
+
+func (ctl *Controller) UpdateDoctorSchedules(c *gin.Context) {
+	// Parse doctorID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_doctor_id", "Invalid doctor ID"))
+		return
+	}
+
+	if ctl.doctors.GetDoctorByID(c.Request.Context(), id.String()) == nil {
+		abort(c, services.ErrDoctorNotFound)
+		return
+	}
+
+	var input schemas.DoctorScheduleInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	schedules, err := services.UpdateDoctorSchedules(c.Request.Context(), id, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Schedules updated successfully", "schedules": schedules})
+}
+
+func (ctl *Controller) GetAvailableSlots(c *gin.Context) {
+	// Parse doctorID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_doctor_id", "Invalid doctor ID"))
+		return
+	}
+
+	// the date defaults to today
+	date := time.Now()
+	if dateParam := c.Query("date"); dateParam != "" {
+		date, err = time.ParseInLocation("2006-01-02", dateParam, time.Local)
+		if err != nil {
+			abort(c, services.Validation("invalid_date", "Invalid date, use YYYY-MM-DD"))
+			return
+		}
+	}
+
+	slots, err := services.GetAvailableSlots(c.Request.Context(), id, date)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"date": date.Format("2006-01-02"), "slots": slots})
+}
+
+func (ctl *Controller) SaveConsultationNote(c *gin.Context) {
+	sessionId := c.Param("id")
+
+	if _, err := ctl.sessions.GetSessionData(c.Request.Context(), sessionId); err != nil {
+		abort(c, err)
+		return
+	}
+
+	var input schemas.ConsultationNoteInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	note, err := ctl.sessions.SaveConsultationNote(c.Request.Context(), sessionId, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	evaluatePrediagnosis(c.Request.Context(), note.SessionID, &note.DoctorID, input.AIRating)
+	ctl.sessions.NotifyHL7SessionUpdated(c.Request.Context(), note.SessionID)
+
+	c.JSON(200, gin.H{"message": "Consultation note saved successfully", "consultation_note": note})
+}
+
+func (ctl *Controller) GetConsultationNote(c *gin.Context) {
+	note, err := services.GetConsultationNote(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"consultation_note": note})
+}
+
+func (ctl *Controller) GetConsultationNoteHistory(c *gin.Context) {
+	revisions, err := services.GetConsultationNoteHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"revisions": revisions})
+}
+
+// evaluatePrediagnosis stores the doctor's rating and compares the AI prediagnosis right away,
+// failures are only logged since the accuracy worker retries later
+func evaluatePrediagnosis(ctx context.Context, sessionID uuid.UUID, doctorID *uuid.UUID, rating *int) {
+	if rating != nil {
+		if err := services.RatePrediagnosis(ctx, sessionID, doctorID, *rating); err != nil {
+			slog.ErrorContext(ctx, "Error saving prediagnosis rating", "session_id", sessionID, "error", err)
+		}
+	}
+
+	if err := services.ReconcileSession(ctx, sessionID); err != nil {
+		slog.ErrorContext(ctx, "Error evaluating prediagnosis", "session_id", sessionID, "error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/BeeCodingAI/triana-api/logging"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+var errorStatus = map[services.ErrorKind]int{
+	services.KindNotFound:     404,
+	services.KindValidation:   400,
+	services.KindConflict:     409,
+	services.KindUnauthorized: 401,
+	services.KindUpstream:     502,
+}
+
+// ErrorHandler writes the error a handler added with c.Error as a schemas.ErrorResponse, the status
+// follows the kind of a services.Error and anything else is an internal error whose cause is only logged
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		ctx := c.Request.Context()
+
+		response := schemas.ErrorResponse{RequestID: logging.RequestID(ctx)}
+		status := 500
+
+		var serviceErr *services.Error
+		if errors.As(err, &serviceErr) {
+			status = errorStatus[serviceErr.Kind]
+			response.Code = serviceErr.Code
+			response.Details = serviceErr.Details
+			response.Message = err.Error() // e.g. "unknown drug: amoxicilin"
+			if serviceErr.Kind == services.KindUpstream {
+				response.Message = serviceErr.Message
+				slog.ErrorContext(ctx, "Upstream error", "code", serviceErr.Code, "error", err)
+			}
+		} else {
+			response.Code = "internal_error"
+			response.Message = "Internal server error"
+			slog.ErrorContext(ctx, "Internal error", "error", err)
+		}
+
+		c.JSON(status, response)
+	}
+}
+
+// abort hands the error to ErrorHandler, the handler returns right after
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// bindAndValidate binds the JSON body to input and validates it, on failure the error is already
+// handed to ErrorHandler and the handler only returns
+func bindAndValidate(c *gin.Context, input interface{}) bool {
+	if err := c.ShouldBindJSON(input); err != nil {
+		slog.WarnContext(c.Request.Context(), "Error binding JSON", "error", err)
+		abort(c, services.Validation("invalid_json", "Invalid JSON format"))
+		return false
+	}
+
+	if err := validate.Struct(input); err != nil {
+		// the failed tag per field
+		details := map[string]any{}
+		for _, err := range err.(validator.ValidationErrors) {
+			details[err.Field()] = err.Tag()
+		}
+		abort(c, services.Validation("validation_failed", "Validation failed").WithDetails(details))
+		return false
+	}
+
+	return true
+}
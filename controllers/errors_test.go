@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/logging"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// serveError runs handler behind the logging and error middlewares and returns the decoded error body
+func serveError(t *testing.T, handler gin.HandlerFunc, body string) (int, schemas.ErrorResponse) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(logging.Middleware(), ErrorHandler())
+	r.POST("/", handler)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+	var response schemas.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid error body %q: %v", w.Body.String(), err)
+	}
+	if response.RequestID == "" || response.RequestID != w.Header().Get(logging.RequestIDHeader) {
+		t.Errorf("request_id = %q, want the X-Request-ID of the response", response.RequestID)
+	}
+	return w.Code, response
+}
+
+func TestErrorHandlerMapsKinds(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{services.ErrUserNotFound, 404, "user_not_found"},
+		{services.ErrInvalidOTP, 401, "invalid_otp"},
+		{services.ErrSlotUnavailable, 409, "slot_unavailable"},
+		{fmt.Errorf("%w: J99.9", services.ErrUnknownICD10Code), 400, "unknown_icd10_code"},
+	}
+
+	for _, tt := range tests {
+		status, response := serveError(t, func(c *gin.Context) { abort(c, tt.err) }, "")
+		if status != tt.status || response.Code != tt.code {
+			t.Errorf("%v: got %d %q, want %d %q", tt.err, status, response.Code, tt.status, tt.code)
+		}
+		if response.Message != tt.err.Error() {
+			t.Errorf("message = %q, want %q", response.Message, tt.err.Error())
+		}
+	}
+}
+
+func TestErrorHandlerHidesCauses(t *testing.T) {
+	status, response := serveError(t, func(c *gin.Context) {
+		abort(c, errors.New("pq: connection refused"))
+	}, "")
+	if status != 500 || response.Code != "internal_error" || strings.Contains(response.Message, "pq") {
+		t.Errorf("got %d %+v, want a generic internal error", status, response)
+	}
+
+	status, response = serveError(t, func(c *gin.Context) {
+		abort(c, services.Upstream("llm_unavailable", "the assistant is not available", errors.New("quota exceeded")))
+	}, "")
+	if status != 502 || response.Code != "llm_unavailable" || strings.Contains(response.Message, "quota") {
+		t.Errorf("got %d %+v, want the upstream message without its cause", status, response)
+	}
+
+	status, response = serveError(t, func(c *gin.Context) {
+		abort(c, invalidLLMResponse(errors.New(`unknown doctor ID "dr-1"`)))
+	}, "")
+	if status != 502 || response.Code != "llm_invalid_response" || strings.Contains(response.Message, "dr-1") {
+		t.Errorf("got %d %+v, want an upstream error for the assistant's reply", status, response)
+	}
+}
+
+func TestBindAndValidateDetails(t *testing.T) {
+	handler := func(c *gin.Context) {
+		var input schemas.OTPInput
+		if !bindAndValidate(c, &input) {
+			return
+		}
+		c.JSON(200, input)
+	}
+
+	status, response := serveError(t, handler, "{")
+	if status != 400 || response.Code != "invalid_json" {
+		t.Errorf("got %d %q, want 400 invalid_json", status, response.Code)
+	}
+
+	status, response = serveError(t, handler, `{"email": "not-an-email"}`)
+	if status != 400 || response.Code != "validation_failed" {
+		t.Errorf("got %d %q, want 400 validation_failed", status, response.Code)
+	}
+	if response.Details["Email"] != "email" {
+		t.Errorf("details = %v, want the failed tag of Email", response.Details)
+	}
+}
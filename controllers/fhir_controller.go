@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fhirJSON responds with the FHIR JSON media type
+func fhirJSON(c *gin.Context, status int, resource schemas.FHIRResource) {
+	c.Header("Content-Type", "application/fhir+json; charset=utf-8")
+	c.JSON(status, resource)
+}
+
+// fhirError responds with an OperationOutcome, FHIR clients expect it instead of our usual message
+func fhirError(c *gin.Context, status int, code string, diagnostics string) {
+	fhirJSON(c, status, schemas.FHIROperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []schemas.FHIROperationOutcomeIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	})
+}
+
+// fhirServerError logs the cause of a failed export, the client only gets a generic OperationOutcome
+func fhirServerError(c *gin.Context, err error) {
+	slog.ErrorContext(c.Request.Context(), "Error exporting FHIR resources", "path", c.FullPath(), "error", err)
+	fhirError(c, 500, "exception", "An internal error occurred while exporting the resources")
+}
+
+func (ctl *Controller) GetFHIRPatient(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		fhirError(c, 400, "invalid", "Invalid patient ID")
+		return
+	}
+
+	if ctl.users.GetUserByID(c.Request.Context(), id) == nil {
+		fhirError(c, 404, "not-found", "Patient not found")
+		return
+	}
+
+	patient, err := ctl.users.GetFHIRPatient(c.Request.Context(), id)
+	if err != nil {
+		fhirServerError(c, err)
+		return
+	}
+
+	fhirJSON(c, 200, *patient)
+}
+
+func (ctl *Controller) SearchFHIREncounters(c *gin.Context) {
+	// only the search by patient is supported, either "<id>" or "Patient/<id>"
+	patient := c.Query("patient")
+	if patient == "" {
+		fhirError(c, 400, "required", "Search parameter patient is required")
+		return
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(patient, "Patient/"))
+	if err != nil {
+		fhirError(c, 400, "invalid", "Invalid patient ID")
+		return
+	}
+
+	if ctl.users.GetUserByID(c.Request.Context(), id) == nil {
+		fhirError(c, 404, "not-found", "Patient not found")
+		return
+	}
+
+	bundle, err := ctl.users.GetFHIREncounters(c.Request.Context(), id)
+	if err != nil {
+		fhirServerError(c, err)
+		return
+	}
+
+	fhirJSON(c, 200, *bundle)
+}
+
+func (ctl *Controller) GetFHIRPatientEverything(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		fhirError(c, 400, "invalid", "Invalid patient ID")
+		return
+	}
+
+	if ctl.users.GetUserByID(c.Request.Context(), id) == nil {
+		fhirError(c, 404, "not-found", "Patient not found")
+		return
+	}
+
+	bundle, err := ctl.users.GetFHIRPatientEverything(c.Request.Context(), id)
+	if err != nil {
+		fhirServerError(c, err)
+		return
+	}
+
+	fhirJSON(c, 200, *bundle)
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz tells the process is up, it doesn't touch any dependency so a database outage doesn't restart the API
+func (ctl *Controller) Healthz(c *gin.Context) {
+	c.JSON(200, gin.H{"status": services.HealthStatusOK})
+}
+
+// Readyz reports every dependency, it answers 503 only when a required one is down
+func (ctl *Controller) Readyz(c *gin.Context) {
+	report := ctl.health.Readiness(c.Request.Context())
+
+	if report.Status == services.HealthStatusUnavailable {
+		c.JSON(503, report)
+		return
+	}
+	c.JSON(200, report)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+func (ctl *Controller) SearchICD10(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		abort(c, services.Validation("missing_query", "Query parameter q is required"))
+		return
+	}
+
+	// limit the number of results, 20 by default
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		abort(c, services.Validation("invalid_limit", "Limit must be between 1 and 100"))
+		return
+	}
+
+	codes, err := services.SearchICD10(query, limit)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"codes": codes})
+}
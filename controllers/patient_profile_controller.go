@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (ctl *Controller) GetPatientProfile(c *gin.Context) {
+	// Parse userID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_user_id", "Invalid user ID"))
+		return
+	}
+
+	if ctl.users.GetUserByID(c.Request.Context(), id) == nil {
+		abort(c, services.ErrUserNotFound)
+		return
+	}
+
+	c.JSON(200, gin.H{"profile": services.GetPatientProfile(id)})
+}
+
+func (ctl *Controller) UpdatePatientProfile(c *gin.Context) {
+	// Parse userID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_user_id", "Invalid user ID"))
+		return
+	}
+
+	user := ctl.users.GetUserByID(c.Request.Context(), id)
+	if user == nil {
+		abort(c, services.ErrUserNotFound)
+		return
+	}
+
+	var input schemas.PatientProfileInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	profile, err := services.UpdatePatientProfile(user, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	ctl.sessions.NotifyHL7PatientUpdated(c.Request.Context(), id)
+
+	c.JSON(200, gin.H{"message": "Profile updated successfully", "profile": profile})
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (ctl *Controller) SearchDrugs(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		abort(c, services.Validation("missing_query", "Query parameter q is required"))
+		return
+	}
+
+	// limit the number of results, 20 by default
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		abort(c, services.Validation("invalid_limit", "Limit must be between 1 and 100"))
+		return
+	}
+
+	drugs, err := services.SearchDrugs(query, limit)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"drugs": drugs})
+}
+
+func (ctl *Controller) CreatePrescriptions(c *gin.Context) {
+	sessionId := c.Param("id")
+
+	if _, err := ctl.sessions.GetSessionData(c.Request.Context(), sessionId); err != nil {
+		abort(c, err)
+		return
+	}
+
+	var input schemas.PrescriptionInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	prescriptions, conflicts, err := ctl.sessions.CreatePrescriptions(c.Request.Context(), sessionId, input)
+	if errors.Is(err, services.ErrAllergyConflict) {
+		// the doctor has to confirm with override_allergy
+		abort(c, services.ErrAllergyConflict.WithDetails(map[string]any{"allergy_conflicts": conflicts}))
+		return
+	}
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":           "Prescriptions saved successfully",
+		"prescriptions":     prescriptions,
+		"allergy_conflicts": conflicts,
+	})
+}
+
+func (ctl *Controller) GetPrescriptions(c *gin.Context) {
+	prescriptions, err := services.GetPrescriptions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"prescriptions": prescriptions})
+}
+
+func (ctl *Controller) GetPatientAllergies(c *gin.Context) {
+	// Parse userID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_user_id", "Invalid user ID"))
+		return
+	}
+
+	if ctl.users.GetUserByID(c.Request.Context(), id) == nil {
+		abort(c, services.ErrUserNotFound)
+		return
+	}
+
+	c.JSON(200, gin.H{"allergies": services.GetPatientAllergies(id)})
+}
+
+func (ctl *Controller) AddPatientAllergy(c *gin.Context) {
+	// Parse userID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_user_id", "Invalid user ID"))
+		return
+	}
+
+	if ctl.users.GetUserByID(c.Request.Context(), id) == nil {
+		abort(c, services.ErrUserNotFound)
+		return
+	}
+
+	var input schemas.AllergyInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	allergy, err := services.AddPatientAllergy(id, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	ctl.sessions.NotifyHL7PatientUpdated(c.Request.Context(), id)
+
+	c.JSON(200, gin.H{"message": "Allergy saved successfully", "allergy": allergy})
+}
+
+func (ctl *Controller) DeletePatientAllergy(c *gin.Context) {
+	// Parse userID to UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_user_id", "Invalid user ID"))
+		return
+	}
+
+	deleted, err := services.DeletePatientAllergy(id, c.Param("allergy_id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+	if !deleted {
+		abort(c, services.NotFound("allergy_not_found", "Allergy not found"))
+		return
+	}
+
+	ctl.sessions.NotifyHL7PatientUpdated(c.Request.Context(), id)
+
+	c.JSON(200, gin.H{"message": "Allergy deleted successfully"})
+}
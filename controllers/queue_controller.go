@@ -1,12 +1,19 @@
 package controllers
 
 import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
 	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/BeeCodingAI/triana-api/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func GetCurrentQueue(c *gin.Context) {
+func (ctl *Controller) GetCurrentQueue(c *gin.Context) {
 	// Get the current queue for the user
 	doctorID := c.Param("doctor_id")
 
@@ -14,25 +21,273 @@ func GetCurrentQueue(c *gin.Context) {
 	doctorUUID, err := uuid.Parse(doctorID)
 
 	if err != nil {
-		c.JSON(400, gin.H{"message": "Invalid doctor ID"})
+		abort(c, services.Validation("invalid_doctor_id", "Invalid doctor ID"))
 		return
 	}
 
 	// get the current queue
-	queue, err := services.GetCurrentQueue(doctorUUID)
+	queue, err := ctl.queues.GetCurrentQueue(c.Request.Context(), doctorUUID)
 	if err != nil {
-		c.JSON(500, gin.H{"message": err.Error()})
+		abort(c, err)
 		return
 	}
 
 	// check if queue is nil
 	if queue == nil {
-		c.JSON(404, gin.H{"message": "No queue found"})
+		abort(c, services.NotFound("queue_empty", "No queue found"))
 		return
 	}
 
 	// return the queue
 	c.JSON(200, gin.H{
-		"queue": queue,
+		"queue":           queue,
+		"patient_profile": services.GetPatientProfile(queue.Session.UserID),
+		"intake_summary":  services.GetIntakeSummary(queue.SessionID),
+		"handoff_summary": services.GetHandoffSummary(c.Request.Context(), queue.SessionID),
+	})
+}
+
+func (ctl *Controller) GetQueueCalendar(c *gin.Context) {
+	// parse the queue entry ID to UUID
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_queue_id", "Invalid queue ID"))
+		return
+	}
+
+	ics, err := ctl.queues.GenerateQueueCalendar(c.Request.Context(), queueID)
+	if err != nil {
+		abort(c, services.ErrQueueNotFound)
+		return
+	}
+
+	// serve the invitation as a downloadable .ics file
+	c.Header("Content-Disposition", `attachment; filename="appointment.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", []byte(ics))
+}
+
+func (ctl *Controller) CancelQueue(c *gin.Context) {
+	// parse the queue entry ID to UUID
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_queue_id", "Invalid queue ID"))
+		return
+	}
+
+	// the link in the queue email carries the token
+	if !utils.VerifyToken("queue/cancel", queueID.String(), c.Query("token")) {
+		abort(c, services.ErrInvalidToken)
+		return
+	}
+
+	if ctl.queues.GetQueueByID(c.Request.Context(), queueID) == nil {
+		abort(c, services.ErrQueueNotFound)
+		return
+	}
+
+	queue, err := ctl.queues.CancelQueue(c.Request.Context(), queueID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// the doctor's current queue moves on without the cancelled entry
+	currentQueue, _ := ctl.queues.GetCurrentQueue(c.Request.Context(), queue.DoctorID)
+
+	c.JSON(200, gin.H{
+		"message":       "Queue entry cancelled successfully",
+		"queue_id":      queue.ID,
+		"status":        queue.Status,
+		"current_queue": currentQueue,
+	})
+}
+
+func (ctl *Controller) RescheduleQueue(c *gin.Context) {
+	// parse the queue entry ID to UUID
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_queue_id", "Invalid queue ID"))
+		return
+	}
+
+	// the link in the queue email carries the token
+	if !utils.VerifyToken("queue/reschedule", queueID.String(), c.Query("token")) {
+		abort(c, services.ErrInvalidToken)
+		return
+	}
+
+	oldQueue := ctl.queues.GetQueueByID(c.Request.Context(), queueID)
+	if oldQueue == nil {
+		abort(c, services.ErrQueueNotFound)
+		return
+	}
+
+	var input schemas.RescheduleInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	queue, appointment, err := ctl.queues.RescheduleQueue(c.Request.Context(), queueID, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// send the new queue number or appointment to the patient
+	var currentQueue *models.Queue
+	if queue != nil {
+		currentQueue, _ = ctl.queues.GetCurrentQueue(c.Request.Context(), queue.DoctorID)
+		ctl.sendQueueNotification(c.Request.Context(), oldQueue.Session.User.Email, queue, currentQueue)
+	} else {
+		ctl.sendAppointmentNotification(c.Request.Context(), oldQueue.Session.User.Email, appointment)
+	}
+
+	c.JSON(200, gin.H{
+		"message":       "Queue entry rescheduled successfully",
+		"queue":         queue,        // queue is nil if the entry was moved to a later date
+		"current_queue": currentQueue, // currentQueue is nil if the entry was moved to a later date
+		"appointment":   appointment,  // appointment is nil if the entry was moved to another doctor today
+	})
+}
+
+// sendQueueNotification emails the queue number with its calendar invitation, failures are only logged
+func (ctl *Controller) sendQueueNotification(ctx context.Context, to string, queue *models.Queue, currentQueue *models.Queue) {
+	// attach the appointment as a calendar invitation, the email is still sent without it
+	var attachments []schemas.EmailAttachment
+	ics, err := ctl.queues.GenerateQueueCalendar(ctx, queue.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating calendar invitation", "queue_id", queue.ID, "error", err)
+	} else {
+		attachments = append(attachments, services.NewCalendarAttachment(ics))
+	}
+
+	// attach the check-in QR code for mail clients that block remote images
+	qrCode, err := services.GenerateCheckInQRCode(*queue)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating check-in QR code", "queue_id", queue.ID, "error", err)
+	} else {
+		attachments = append(attachments, schemas.EmailAttachment{
+			Filename:    "check-in.png",
+			Content:     base64.StdEncoding.EncodeToString(qrCode),
+			ContentType: "image/png",
+		})
+	}
+
+	// the patient is first in line when nobody else is waiting
+	currentNumber := queue.Number
+	if currentQueue != nil {
+		currentNumber = currentQueue.Number
+	}
+
+	_, err = services.SendQueueEmail(ctx, to, *queue, currentNumber, ctl.cfg.Email.Token, attachments)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending queue email", "queue_id", queue.ID, "error", err)
+	}
+}
+
+func (ctl *Controller) CheckInQueue(c *gin.Context) {
+	// parse the queue entry ID to UUID
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_queue_id", "Invalid queue ID"))
+		return
+	}
+
+	// the kiosk reads the token from the QR code in the queue email
+	if !utils.VerifyToken("queue/check-in", queueID.String(), c.Query("token")) {
+		abort(c, services.ErrInvalidToken)
+		return
+	}
+
+	if ctl.queues.GetQueueByID(c.Request.Context(), queueID) == nil {
+		abort(c, services.ErrQueueNotFound)
+		return
+	}
+
+	queue, err := ctl.queues.CheckInQueue(c.Request.Context(), queueID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	currentQueue, _ := ctl.queues.GetCurrentQueue(c.Request.Context(), queue.DoctorID)
+
+	c.JSON(200, gin.H{
+		"message":       "Checked in successfully",
+		"queue_id":      queue.ID,
+		"number":        queue.Number,
+		"arrived_at":    queue.ArrivedAt,
+		"room":          queue.Doctor.Roomno,
+		"current_queue": currentQueue,
+	})
+}
+
+func (ctl *Controller) GetCheckInQRCode(c *gin.Context) {
+	// parse the queue entry ID to UUID
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_queue_id", "Invalid queue ID"))
+		return
+	}
+
+	// the QR code contains the check-in token, so it needs the same token to be shown
+	if !utils.VerifyToken("queue/check-in", queueID.String(), c.Query("token")) {
+		abort(c, services.ErrInvalidToken)
+		return
+	}
+
+	queue := ctl.queues.GetQueueByID(c.Request.Context(), queueID)
+	if queue == nil {
+		abort(c, services.ErrQueueNotFound)
+		return
+	}
+
+	png, err := services.GenerateCheckInQRCode(*queue)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Data(200, "image/png", png)
+}
+
+func (ctl *Controller) TransferQueue(c *gin.Context) {
+	// parse the queue entry ID to UUID
+	queueID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		abort(c, services.Validation("invalid_queue_id", "Invalid queue ID"))
+		return
+	}
+
+	oldQueue := ctl.queues.GetQueueByID(c.Request.Context(), queueID)
+	if oldQueue == nil {
+		abort(c, services.ErrQueueNotFound)
+		return
+	}
+
+	var input schemas.TransferQueueInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	queue, err := ctl.queues.TransferQueue(c.Request.Context(), queueID, input)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// the new doctor gets the handoff summary in their own language
+	ctl.sessions.GenerateHandoffSummaryAsync(c.Request.Context(), queue.SessionID.String(), queue.DoctorID.String())
+	ctl.sessions.NotifyHL7SessionUpdated(c.Request.Context(), queue.SessionID)
+
+	// let the patient know where to go
+	_, err = services.SendQueueTransferEmail(c.Request.Context(), oldQueue.Session.User.Email, *queue, oldQueue.Doctor, ctl.cfg.Email.Token)
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error sending queue transfer email", "queue_id", queue.ID, "error", err)
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Queue entry transferred successfully",
+		"queue":   queue,
 	})
 }
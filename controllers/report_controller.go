@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+func (ctl *Controller) GetPrediagnosisAccuracyReport(c *gin.Context) {
+	// the last 30 days by week by default, "to" is inclusive
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -30)
+
+	var err error
+	if toParam := c.Query("to"); toParam != "" {
+		to, err = time.ParseInLocation("2006-01-02", toParam, time.Local)
+		if err != nil {
+			abort(c, services.Validation("invalid_date", "Invalid to date, use YYYY-MM-DD"))
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err = time.ParseInLocation("2006-01-02", fromParam, time.Local)
+		if err != nil {
+			abort(c, services.Validation("invalid_date", "Invalid from date, use YYYY-MM-DD"))
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		abort(c, services.Validation("invalid_range", "from must be before to"))
+		return
+	}
+
+	report, err := services.GetAccuracyReport(c.Request.Context(), from, to, c.DefaultQuery("period", "week"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, report)
+}
@@ -1,32 +1,24 @@
 package controllers
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"log/slog"
 
-	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/metrics"
 	"github.com/BeeCodingAI/triana-api/models"
 	"github.com/BeeCodingAI/triana-api/schemas"
 	"github.com/BeeCodingAI/triana-api/services"
-	"github.com/BeeCodingAI/triana-api/utils"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func GenerateSessionResponse(c *gin.Context) {
+func (ctl *Controller) GenerateSessionResponse(c *gin.Context) {
 	session_id := c.Param("id")
 
 	// check if session_id exists in the database
-	var existingSession models.Session
-
-	err := config.DB.Preload("User").
-		Preload("Messages", func(db *gorm.DB) *gorm.DB {
-			return db.Order("created_at ASC") // Order messages by created_at in ascending order (for earlier messages first)
-		}).
-		Where("id = ?", session_id).First(&existingSession).Error
-
+	existingSession, err := ctl.sessions.GetSessionData(c.Request.Context(), session_id)
 	if err != nil {
-		c.JSON(404, gin.H{"message": "Session not found"})
+		abort(c, err)
 		return
 	}
 
@@ -34,82 +26,123 @@ func GenerateSessionResponse(c *gin.Context) {
 	var input schemas.SessionChatInput
 
 	// bind and validate the input
-	if valid, _ := utils.BindAndValidate(c, &input); !valid {
-		return // The response has already been sent in the utility function
+	if !bindAndValidate(c, &input) {
+		return
 	}
 
 	// get the message reply from LLM
-	reply, err := services.GetLLMResponse(input.NewMessage, &existingSession)
+	reply, err := ctl.sessions.GetLLMResponse(c.Request.Context(), input.NewMessage, &existingSession)
 	if err != nil {
-		c.JSON(500, gin.H{"message": err.Error()})
+		abort(c, err)
 		return
 	}
 
-	// parse the LLM response
-	var LLMResponse schemas.LLMResponse
-	LLMResponse, err = services.ParseJSON(reply)
+	// parse the LLM response, a malformed reply is a fault of the assistant, not of the client
+	LLMResponse, err := services.ParseJSON(reply)
+	if err != nil {
+		metrics.CountNextAction("INVALID")
+		abort(c, invalidLLMResponse(err))
+		return
+	}
 
 	// queue var
 	var queue *models.Queue = nil
 	var currentQueue *models.Queue
+	var appointment *models.Appointment
 
 	// from the LLM response determine the next action
-	log.Println("LLM Response Next Action:", LLMResponse.NextAction)
-	log.Println("-----------------------------------")
-	log.Println("LLM Response Doctor ID:", LLMResponse.DoctorID)
-	log.Println("-----------------------------------")
-	log.Println("LLM Response:", LLMResponse.Reply)
+	slog.InfoContext(c.Request.Context(), "LLM response",
+		"session_id", session_id,
+		"next_action", LLMResponse.NextAction,
+		"doctor_id", LLMResponse.DoctorID,
+		"reply", LLMResponse.Reply,
+	)
 	if next_action := LLMResponse.NextAction; next_action == "CONTINUE_CHAT" {
 		// just continue
 
 	} else if next_action == "APPOINTMENT" {
-		// create queue
-		queue, err = services.GenerateQueue(session_id, LLMResponse.DoctorID)
-		if err != nil {
-			c.JSON(500, gin.H{"message": err.Error()})
+		// the doctor is picked by the assistant from the list in its prompt
+		if ctl.doctors.GetDoctorByID(c.Request.Context(), LLMResponse.DoctorID) == nil {
+			metrics.CountNextAction("INVALID")
+			abort(c, invalidLLMResponse(fmt.Errorf("unknown doctor ID %q", LLMResponse.DoctorID)))
 			return
 		}
 
-		// preload queue's doctor
-		err = config.DB.Preload("Doctor").Where("id = ?", queue.ID).First(&queue).Error
-		if err != nil {
-			c.JSON(500, gin.H{"message": err.Error()})
-			return
+		if appointmentDate, ok := services.ParseFutureDate(LLMResponse.AppointmentDate); ok {
+			// the patient wants to come on a later date, book a slot instead of a queue number
+			appointment, err = services.BookFirstAvailableSlot(c.Request.Context(), session_id, LLMResponse.DoctorID, appointmentDate)
+			if errors.Is(err, services.ErrSlotUnavailable) {
+				// keep the chat going so the patient can pick another date
+				LLMResponse.NextAction = "CONTINUE_CHAT"
+				LLMResponse.Reply = fmt.Sprintf(
+					"Maaf, tidak ada jadwal yang tersedia pada %s. Silakan pilih tanggal lain. / Sorry, there is no available slot on %s. Please choose another date.",
+					appointmentDate.Format("2006-01-02"), appointmentDate.Format("2006-01-02"),
+				)
+			} else if err != nil {
+				abort(c, err)
+				return
+			} else {
+				ctl.sendAppointmentNotification(c.Request.Context(), existingSession.User.Email, appointment)
+			}
+
+		} else {
+			// create queue
+			queue, err = ctl.queues.GenerateQueue(c.Request.Context(), session_id, LLMResponse.DoctorID)
+			if err != nil {
+				abort(c, err)
+				return
+			}
+
+			// preload queue's doctor
+			queue, err = ctl.queues.GetQueueWithDoctor(c.Request.Context(), queue.ID)
+			if err != nil {
+				abort(c, err)
+				return
+			}
+
+			// send email to the user, currentQueue is nil while no patient has checked in yet
+			currentQueue, _ = ctl.queues.GetCurrentQueue(c.Request.Context(), queue.DoctorID)
+			ctl.sendQueueNotification(c.Request.Context(), existingSession.User.Email, queue, currentQueue)
 		}
 
-		// send email to the user
-		currentQueue, err = services.GetCurrentQueue(queue.DoctorID)
-		if err != nil {
-			c.JSON(500, gin.H{"message": err.Error()})
-			return
-		}
-
-		_, err = services.SendQueueEmail(existingSession.User.Email, queue.Number, currentQueue.Number, os.Getenv("EMAIL_TOKEN"), queue.Doctor)
-		if err != nil {
-			log.Println("Error sending email:", err)
-		}
-
-		// update the session's prediagnosis
-		existingSession.Prediagnosis = LLMResponse.PreDiagnosis
-
-		err = config.DB.Save(&existingSession).Error
-		if err != nil {
-			c.JSON(500, gin.H{"message": err.Error()})
-			return
+		if LLMResponse.NextAction == "APPOINTMENT" {
+			// update the session's prediagnosis
+			err = ctl.sessions.SavePrediagnosis(c.Request.Context(), &existingSession, LLMResponse.PreDiagnosis)
+			if err != nil {
+				abort(c, err)
+				return
+			}
+
+			// store the candidate ICD-10 codes to compare them with the doctor's diagnosis later
+			if err := services.SaveAIDiagnosisCodes(c.Request.Context(), existingSession.ID, LLMResponse.ICD10Codes); err != nil {
+				slog.ErrorContext(c.Request.Context(), "Error saving ICD-10 codes", "session_id", session_id, "error", err)
+			}
+
+			// the hospital system gets the attending doctor and the prediagnosis
+			ctl.sessions.NotifyHL7SessionUpdated(c.Request.Context(), existingSession.ID)
 		}
 
 	} else {
-		c.JSON(500, gin.H{"message": "Invalid next action"})
+		metrics.CountNextAction("INVALID")
+		abort(c, invalidLLMResponse(fmt.Errorf("invalid next action %q", LLMResponse.NextAction)))
 		return
 	}
 
 	// update the chat history with the new message and LLM response
-	err = services.UpdateChatHistory(session_id, input.NewMessage, LLMResponse.Reply)
+	err = ctl.sessions.UpdateChatHistory(c.Request.Context(), session_id, input.NewMessage, LLMResponse.Reply)
 	if err != nil {
-		c.JSON(500, gin.H{"message": err.Error()})
+		abort(c, err)
 		return
 	}
 
+	// the chat is over, extract the symptoms and write the handoff summary of the whole transcript for the doctor
+	if LLMResponse.NextAction == "APPOINTMENT" {
+		ctl.sessions.ExtractSessionSymptomsAsync(c.Request.Context(), session_id)
+		ctl.sessions.GenerateHandoffSummaryAsync(c.Request.Context(), session_id, LLMResponse.DoctorID)
+	}
+
+	metrics.CountNextAction(LLMResponse.NextAction)
+
 	// send the response back to the client
 	c.JSON(200, gin.H{
 		"message":       "Chat history updated successfully",
@@ -118,26 +151,100 @@ func GenerateSessionResponse(c *gin.Context) {
 		"session_id":    session_id,
 		"queue":         queue,        // queue is nil if next_action is not APPOINTMENT
 		"current_queue": currentQueue, // currentQueue is nil if next_action is not APPOINTMENT
+		"appointment":   appointment,  // appointment is only set when a future date was booked
 	})
 }
 
-func GetActiveSession(c *gin.Context) {
+func (ctl *Controller) GetActiveSession(c *gin.Context) {
 	session_id := c.Param("id")
 
 	var session models.Session
 
-	session, err := services.GetSessionData(session_id)
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), session_id)
 	if err != nil {
-		c.JSON(404, gin.H{"message": "Session not found"})
+		abort(c, err)
 		return
 	}
 
 	// make sure sessoin is active (prediagnosis is not done yet)
 	if session.Prediagnosis != "" {
-		c.JSON(400, gin.H{"message": "Session is completed"})
+		abort(c, services.Conflict("session_completed", "Session is completed"))
 		return
 	}
 
 	// send the response back to the client
 	c.JSON(200, session)
 }
+
+func (ctl *Controller) GetSessionSymptoms(c *gin.Context) {
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"intake_summary": services.GetIntakeSummary(session.ID)})
+}
+
+func (ctl *Controller) ExtractSessionSymptoms(c *gin.Context) {
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// extract again, e.g. when the automatic extraction at appointment time failed
+	if _, err := ctl.sessions.ExtractSessionSymptoms(c.Request.Context(), session.ID.String()); err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Symptoms extracted successfully", "intake_summary": services.GetIntakeSummary(session.ID)})
+}
+
+func (ctl *Controller) GetHandoffSummary(c *gin.Context) {
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	summary := services.GetHandoffSummary(c.Request.Context(), session.ID)
+	if summary == nil {
+		abort(c, services.NotFound("handoff_summary_not_found", "Handoff summary not found"))
+		return
+	}
+
+	c.JSON(200, gin.H{"handoff_summary": summary})
+}
+
+func (ctl *Controller) GenerateHandoffSummary(c *gin.Context) {
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	var input schemas.HandoffSummaryInput
+	if !bindAndValidate(c, &input) {
+		return
+	}
+
+	if ctl.doctors.GetDoctorByID(c.Request.Context(), input.DoctorID) == nil {
+		abort(c, services.ErrDoctorNotFound)
+		return
+	}
+
+	summary, err := ctl.sessions.GenerateHandoffSummary(c.Request.Context(), session.ID.String(), input.DoctorID)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Handoff summary generated successfully", "handoff_summary": summary})
+}
+
+// invalidLLMResponse reports a reply of the assistant the API cannot act on as an upstream failure
+func invalidLLMResponse(err error) error {
+	return services.Upstream("llm_invalid_response", "the assistant is not available, try again later", err)
+}
@@ -2,8 +2,8 @@ package controllers
 
 import (
 	"sort"
-	"time"
 
+	"github.com/BeeCodingAI/triana-api/models"
 	"github.com/BeeCodingAI/triana-api/schemas"
 	"github.com/BeeCodingAI/triana-api/services"
 	"github.com/BeeCodingAI/triana-api/utils"
@@ -14,19 +14,19 @@ import (
 
 var validate = validator.New()
 
-func RegisterUser(c *gin.Context) {
+func (ctl *Controller) RegisterUser(c *gin.Context) {
 	var input schemas.RegisterUserInput
 
 	// bind and validate the request body to the input struct
-	if valid, _ := utils.BindAndValidate(c, &input); !valid {
-		return // the response has already been sent in the utility function
+	if !bindAndValidate(c, &input) {
+		return
 	}
 
 	// Call the service to register the user
-	user, err := services.RegisterUser(input)
+	user, err := ctl.users.RegisterUser(c.Request.Context(), input)
 
 	if err != nil {
-		c.JSON(500, gin.H{"message": err.Error()})
+		abort(c, err)
 		return
 	}
 
@@ -41,114 +41,101 @@ func RegisterUser(c *gin.Context) {
 	})
 }
 
-func VerifyOTP(c *gin.Context) {
+func (ctl *Controller) VerifyOTP(c *gin.Context) {
 	var input schemas.OTPInput
 
 	// bind and validate the request body to the input struct
-	if valid, _ := utils.BindAndValidate(c, &input); !valid {
-		return // The response has already been sent in the utility function
+	if !bindAndValidate(c, &input) {
+		return
 	}
 
 	// Call the service to verify the OTP
-	session, err := services.ValidateOTP(input)
+	session, err := ctl.users.ValidateOTP(c.Request.Context(), input)
 
 	if err != nil {
-		c.JSON(500, gin.H{"message": err.Error()})
+		abort(c, err)
 		return
 	}
 
+	// register the visit and its vitals in the hospital system
+	ctl.sessions.NotifyHL7SessionCreated(c.Request.Context(), session.ID)
+
 	c.JSON(200, gin.H{"message": "OTP verified successfully", "session": session})
 }
 
-func GetUserDetails(c *gin.Context) {
+func (ctl *Controller) GetUserDetails(c *gin.Context) {
 	userID := c.Param("id")
 
 	// Parse userID to UUID
 	id, err := uuid.Parse(userID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		abort(c, services.Validation("invalid_user_id", "Invalid user ID"))
 		return
 	}
 
 	// Fetch user details
-	user := services.GetUserByID(id)
+	user := ctl.users.GetUserByID(c.Request.Context(), id)
 	if user == nil {
-		c.JSON(404, gin.H{"error": "User not found"})
+		abort(c, services.ErrUserNotFound)
 		return
 	}
 
 	// Fetch sessions for the user
-	sessions := services.GetSessionsByUserID(id)
+	sessions := ctl.sessions.GetSessionsByUserID(c.Request.Context(), id)
 	if sessions == nil {
-		c.JSON(404, gin.H{"error": "No sessions found for the user"})
+		abort(c, services.NotFound("sessions_not_found", "No sessions found for the user"))
 		return
 	}
 
-	// Separate current session and history sessions
-	var currentSession gin.H
-	var historySessions []gin.H
-	latestTimestamp := time.Time{} // Initialize to zero value
-
-	for _, session := range sessions {
-		if session.CreatedAt.After(latestTimestamp) {
-			if currentSession != nil {
-				historySessions = append(historySessions, gin.H{
-					"session_id":       currentSession["session_id"],
-					"bodytemp":         currentSession["bodytemp"],
-					"doctor_diagnosis": currentSession["doctor_diagnosis"],
-					"heartrate":        currentSession["heartrate"],
-					"height":           currentSession["height"],
-					"prediagnosis":     currentSession["prediagnosis"],
-					"weight":           currentSession["weight"],
-					"created_at":       currentSession["created_at"],
-				})
-			}
-			latestTimestamp = session.CreatedAt
-
-			// Fetch queue for the current session
-			queue := services.GetQueueBySessionID(session.ID)
-
-			currentSession = gin.H{
-				"queue":            queue,
-				"session_id":       session.ID,
-				"bodytemp":         session.Bodytemp,
-				"doctor_diagnosis": session.DoctorDiagnosis,
-				"heartrate":        session.Heartrate,
-				"height":           session.Height,
-				"prediagnosis":     session.Prediagnosis,
-				"weight":           session.Weight,
-				"created_at":       session.CreatedAt, // Ensure created_at is set here
-			}
-		} else {
-			historySessions = append(historySessions, gin.H{
-				"session_id":       session.ID,
-				"bodytemp":         session.Bodytemp,
-				"doctor_diagnosis": session.DoctorDiagnosis,
-				"heartrate":        session.Heartrate,
-				"height":           session.Height,
-				"prediagnosis":     session.Prediagnosis,
-				"weight":           session.Weight,
-				"created_at":       session.CreatedAt, // Use session.CreatedAt directly
-			})
+	// the latest session is the current one, the others are the history
+	latest := 0
+	for i := range sessions {
+		if sessions[i].CreatedAt.After(sessions[latest].CreatedAt) {
+			latest = i
+		}
+	}
+
+	details := schemas.UserDetails{
+		User: schemas.PatientDetails{
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			Gender:      user.Gender,
+			Nationality: user.Nationality,
+			Age:         utils.DateToAgeString(user.DOB),
+			Profile:     services.GetPatientProfile(id),
+		},
+	}
+	for i, session := range sessions {
+		if i == latest {
+			current := newSessionDetails(session)
+			current.Queue = ctl.queues.GetQueueBySessionID(c.Request.Context(), session.ID)
+			details.CurrentSession = &current
+			continue
 		}
+		details.HistorySessions = append(details.HistorySessions, newSessionDetails(session))
 	}
 
 	// Sort history sessions by created_at in descending order
-	sort.Slice(historySessions, func(i, j int) bool {
-		return historySessions[i]["created_at"].(time.Time).After(historySessions[j]["created_at"].(time.Time))
+	sort.Slice(details.HistorySessions, func(i, j int) bool {
+		return details.HistorySessions[i].CreatedAt.After(details.HistorySessions[j].CreatedAt)
 	})
 
-	// Respond with the new structure
-	c.JSON(200, gin.H{
-		"user": gin.H{
-			"id":          user.ID,
-			"name":        user.Name,
-			"email":       user.Email,
-			"gender":      user.Gender,
-			"nationality": user.Nationality,
-			"age":         utils.DateToAgeString(user.DOB),
-		},
-		"current_session":  currentSession,
-		"history_sessions": historySessions,
-	})
+	c.JSON(200, details)
+}
+
+func newSessionDetails(session models.Session) schemas.SessionDetails {
+	return schemas.SessionDetails{
+		SessionID:        session.ID,
+		Bodytemp:         session.Bodytemp,
+		DoctorDiagnosis:  session.DoctorDiagnosis,
+		ConsultationNote: session.ConsultationNote,
+		CodedDiagnoses:   session.CodedDiagnoses,
+		Prescriptions:    session.Prescriptions,
+		Heartrate:        session.Heartrate,
+		Height:           session.Height,
+		Prediagnosis:     session.Prediagnosis,
+		Weight:           session.Weight,
+		CreatedAt:        session.CreatedAt,
+	}
 }
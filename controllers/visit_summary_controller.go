@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/gin-gonic/gin"
+)
+
+func (ctl *Controller) GetVisitSummary(c *gin.Context) {
+	summary, err := ctl.sessions.GetVisitSummary(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"summary": summary})
+}
+
+func (ctl *Controller) GetVisitSummaryPDF(c *gin.Context) {
+	summary, err := ctl.sessions.GetVisitSummary(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	pdf, err := services.RenderVisitSummaryPDF(summary)
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// serve the summary as a downloadable PDF file
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, services.VisitSummaryFilename(summary)))
+	c.Data(200, "application/pdf", pdf)
+}
+
+func (ctl *Controller) GetVisitSummaryHTML(c *gin.Context) {
+	summary, err := ctl.sessions.GetVisitSummary(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.Data(200, "text/html; charset=utf-8", []byte(services.RenderVisitSummaryHTML(summary)))
+}
+
+func (ctl *Controller) EmailVisitSummary(c *gin.Context) {
+	session, err := ctl.sessions.GetSessionData(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	summary, err := ctl.sessions.GetVisitSummary(c.Request.Context(), session.ID.String())
+	if err != nil {
+		abort(c, err)
+		return
+	}
+
+	// the summary is only sent to the patient's own address
+	if _, err := services.SendVisitSummaryEmail(c.Request.Context(), session.User.Email, summary, ctl.cfg.Email.Token); err != nil {
+		abort(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Visit summary sent successfully"})
+}
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted replaces the value of a PHI attribute
+const Redacted = "[REDACTED]"
+
+// phiKeys are the attribute keys that hold patient data, they are masked unless debug mode is on
+var phiKeys = map[string]bool{
+	"name":          true,
+	"patient_name":  true,
+	"email":         true,
+	"to":            true,
+	"otp":           true,
+	"dob":           true,
+	"message":       true,
+	"content":       true,
+	"reply":         true,
+	"prompt":        true,
+	"system_prompt": true,
+	"transcript":    true,
+	"diagnosis":     true,
+	"prediagnosis":  true,
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log lines carry the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID of the context, empty outside of a request
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Setup makes the configured handler the default of slog, and of the log package through slog
+func Setup(cfg config.LoggingConfig) {
+	slog.SetDefault(slog.New(NewHandler(os.Stdout, cfg)))
+}
+
+// NewHandler writes JSON or text lines with the request and trace IDs of the context, PHI is masked
+// unless cfg.DebugMode is set
+func NewHandler(w io.Writer, cfg config.LoggingConfig) slog.Handler {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level)) // validated by the config, info when empty
+
+	options := &slog.HandlerOptions{Level: level}
+	if !cfg.DebugMode {
+		options.ReplaceAttr = redact
+	}
+
+	var handler slog.Handler = slog.NewJSONHandler(w, options)
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return &contextHandler{Handler: handler}
+}
+
+// redact masks the PHI attributes, also inside groups, the built-in time, level and msg keys are kept
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && (attr.Key == slog.MessageKey || attr.Key == slog.TimeKey || attr.Key == slog.LevelKey) {
+		return attr
+	}
+	if phiKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// contextHandler adds the request ID and the trace of the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
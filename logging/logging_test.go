@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/gin-gonic/gin"
+)
+
+// logLine logs one line through a new handler and returns it decoded
+func logLine(t *testing.T, cfg config.LoggingConfig, log func(logger *slog.Logger)) map[string]any {
+	t.Helper()
+
+	var out bytes.Buffer
+	log(slog.New(NewHandler(&out, cfg)))
+
+	var line map[string]any
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", out.String(), err)
+	}
+	return line
+}
+
+func TestHandlerRedactsPHI(t *testing.T) {
+	cfg := config.Defaults().Logging
+	line := logLine(t, cfg, func(logger *slog.Logger) {
+		logger.Info("LLM response",
+			"session_id", "3f0c",
+			"reply", "Pak Budi, demam 39°C sejak kemarin",
+			slog.Group("patient", "name", "Budi", "Email", "budi@example.com"),
+		)
+	})
+
+	if line["msg"] != "LLM response" || line["session_id"] != "3f0c" {
+		t.Errorf("line = %v, want the message and the session ID untouched", line)
+	}
+	if line["reply"] != Redacted {
+		t.Errorf("reply = %v, want it redacted", line["reply"])
+	}
+	patient, _ := line["patient"].(map[string]any)
+	if patient["name"] != Redacted || patient["Email"] != Redacted {
+		t.Errorf("patient = %v, want the name and email redacted inside the group", patient)
+	}
+}
+
+func TestHandlerKeepsPHIInDebugMode(t *testing.T) {
+	cfg := config.Defaults().Logging
+	cfg.DebugMode = true
+	line := logLine(t, cfg, func(logger *slog.Logger) {
+		logger.Info("OTP sent", "otp", "123456")
+	})
+
+	if line["otp"] != "123456" {
+		t.Errorf("otp = %v, want it unmasked in debug mode", line["otp"])
+	}
+}
+
+func TestHandlerFiltersByLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewHandler(&out, config.Defaults().Logging))
+	logger.Debug("System prompt", "system_prompt", "...")
+
+	if out.Len() != 0 {
+		t.Errorf("debug line logged at the info level: %s", out.String())
+	}
+}
+
+func TestHandlerAddsRequestID(t *testing.T) {
+	line := logLine(t, config.Defaults().Logging, func(logger *slog.Logger) {
+		logger.InfoContext(WithRequestID(context.Background(), "req-1"), "Queue generated")
+	})
+
+	if line["request_id"] != "req-1" {
+		t.Errorf("request_id = %v, want req-1", line["request_id"])
+	}
+}
+
+func TestMiddlewareAssignsRequestIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	var seen string
+	r.GET("/ping", func(c *gin.Context) {
+		seen = RequestID(c.Request.Context())
+		c.Status(200)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"reuses a valid ID", "lb-7f3a.1", "lb-7f3a.1"},
+		{"replaces an invalid ID", "bad\nid", ""},
+		{"assigns a missing ID", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("response ID %q, handler saw %q, want the same non-empty ID", got, seen)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("request ID = %q, want %q", got, tt.want)
+			}
+			if tt.want == "" && got == tt.header {
+				t.Errorf("request ID %q was not replaced", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in requests from a proxy and in every response
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID keeps IDs from callers short and free of characters that could forge log lines
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Middleware assigns every request an ID, reusing the caller's X-Request-ID when valid, returns it
+// in the response and logs the request when it is done
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), requestID))
+
+		started := time.Now()
+		c.Next()
+
+		// the path without the query, links in emails carry their signed token there
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		slog.Log(c.Request.Context(), level, "request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(started).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		)
+	}
+}
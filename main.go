@@ -1,61 +1,167 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/BeeCodingAI/triana-api/config"
 	"github.com/BeeCodingAI/triana-api/controllers"
+	"github.com/BeeCodingAI/triana-api/logging"
+	"github.com/BeeCodingAI/triana-api/metrics"
+	"github.com/BeeCodingAI/triana-api/migrations"
+	"github.com/BeeCodingAI/triana-api/repositories"
+	"github.com/BeeCodingAI/triana-api/services"
+	"github.com/BeeCodingAI/triana-api/tracing"
+	"github.com/BeeCodingAI/triana-api/utils"
 )
 
+// traceFlushTimeout bounds exporting the last spans at shutdown
+const traceFlushTimeout = 5 * time.Second
+
 func main() {
-	// load .env file
-	if _, err := os.Stat(".env"); err == nil {
-		err = godotenv.Load()
+	// `./main migrate up|down|status` manages the schema instead of starting the server,
+	// it only needs the database settings
+	migrate := len(os.Args) > 1 && os.Args[1] == "migrate"
+	var sections []string
+	if migrate {
+		sections = []string{"Database"}
+	}
+
+	// load the configuration from config.yaml, .env and the environment
+	cfg, err := config.Load(sections...)
+	if err != nil {
+		fatal("Error loading configuration", err)
+	}
+
+	// log JSON lines with the request ID, patient data is masked unless LOG_DEBUG_MODE is set
+	logging.Setup(cfg.Logging)
+	slog.Info("Loaded configuration", "config", cfg.String())
+	if cfg.Logging.DebugMode {
+		slog.Warn("Debug mode is on, logs contain patient data")
+	}
+
+	services.Configure(cfg)
+	utils.SetSigningSecret(cfg.App.LinkSigningSecret)
+
+	// export traces of the requests, queries, Gemini calls and emails
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Error setting up tracing", err)
+	}
+
+	// Connect to the database
+	config.ConnectDatabase(cfg.Database)
+	if err := tracing.InstrumentDatabase(config.DB); err != nil {
+		fatal("Error instrumenting database", err)
+	}
+
+	if migrate {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+
+	// apply pending migrations, replicas starting together wait for each other on the migration lock,
+	// set MIGRATE_ON_START=false to run `migrate up` as a separate deploy step instead
+	if cfg.Database.MigrateOnStart {
+		applied, err := migrations.Up(config.DB)
 		if err != nil {
-			log.Fatal("Error loading .env file")
+			fatal("Failed to migrate database", err)
 		}
-	} else if os.IsNotExist(err) {
-		log.Println(".env file not found, using environment variables from Docker")
-	} else {
-		log.Fatal("Error checking .env file:", err)
+		slog.Info("Database migrated", "applied", len(applied))
 	}
 
-	// Connect to the database
-	config.ConnectDatabase()
+	// load the ICD-10 and drug catalogs on the first start
+	services.SeedICD10Catalog()
+	services.SeedDrugCatalog()
+
+	// stop on SIGINT or SIGTERM, e.g. when a deploy replaces the container
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	runWorker := func(worker func(ctx context.Context, interval time.Duration), interval time.Duration) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			worker(ctx, interval)
+		}()
+	}
+
+	// mark patients who never showed up as NO_SHOW after the clinic closes
+	runWorker(services.RunNoShowWorker, 10*time.Minute)
+
+	// compare AI prediagnoses with doctor diagnoses that were not evaluated yet
+	runWorker(services.RunAccuracyWorker, time.Hour)
+
+	// send registrations, updates and vitals to the hospital system over HL7 v2 when configured
+	if cfg.HL7.MLLPAddress != "" {
+		services.SetHL7Sender(services.NewMLLPSender(cfg.HL7.MLLPAddress, 10*time.Second))
+	}
 
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowAllOrigins = true
+	// wire the services to the database
+	repos := repositories.NewGormRepositories(config.DB)
+	doctors := services.NewDoctorService(repos.Doctors)
+	queues := services.NewQueueService(repos.Queues, doctors)
+	ctl := controllers.NewController(
+		cfg,
+		services.NewUserService(repos.Users, repos.Sessions, services.NewEmailOTPMailer(cfg.Email.OTPToken)),
+		services.NewSessionService(repos.Sessions, repos.Messages, repos.Queues, doctors),
+		queues,
+		doctors,
+		services.NewHealthService(cfg.Health.CacheTTL, cfg.Health.CheckTimeout, services.DefaultHealthChecks(config.DB)...),
+	)
 
-	r := gin.Default()
-	r.Use(cors.New(corsConfig))
+	// export the request, LLM, queue and connection pool metrics on /metrics
+	if sqlDB, err := config.DB.DB(); err == nil {
+		metrics.RegisterDBStats(sqlDB)
+	}
+	metrics.RegisterQueueLength(queues.WaitingQueueLengths)
+
+	r := newRouter(cfg, ctl)
 
-	// register routes
-	r.POST("/register", controllers.RegisterUser)
-	r.POST("/verify-otp", controllers.VerifyOTP)
+	// one SHUTDOWN_TIMEOUT covers draining the requests and the background jobs, it starts with the signal
+	shutdownCtx, cancelShutdown := deadlineAfter(ctx, cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
 
-	// session routes
-	r.GET("/session/:id", controllers.GetActiveSession)
-	r.POST("/session/:id", controllers.GenerateSessionResponse)
-	r.POST("/session/:id/diagnose", controllers.DoctorDiagnose)
+	// serve until a shutdown signal, in-flight requests are drained before serve returns
+	serveErr := serve(ctx, shutdownCtx, cfg.Server, r)
+	if serveErr != nil {
+		slog.Error("Server error", "error", serveErr)
+	}
+	stop()
 
-	// queue routes
-	r.GET("/queue/:doctor_id", controllers.GetCurrentQueue)
+	// let the workers finish their current run and the background jobs, e.g. handoff summaries, complete
+	workers.Wait()
+	if err := services.WaitForBackground(shutdownCtx); err != nil {
+		slog.Error("Background jobs did not finish in time", "error", err)
+	}
 
-	// doctor routes
-	r.GET("/doctor/:id", controllers.GetDoctorDetails)
+	if err := config.CloseDatabase(); err != nil {
+		slog.Error("Error closing database", "error", err)
+	}
 
-	// user routes
-	r.GET("/user/:id", controllers.GetUserDetails)
+	// the last spans are flushed even when the shutdown deadline has passed
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Error flushing traces", "error", err)
+	}
+	slog.Info("Server stopped")
 
-	// test routes
-	r.GET("/ping", func(c *gin.Context) {
-		c.String(200, "pong")
-	})
+	if serveErr != nil {
+		os.Exit(1)
+	}
+}
 
-	r.Run(":8080") // listen and serve on
+// fatal logs the error and exits, deferred functions don't run
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "triana"
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by route pattern.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, // chat replies wait for Gemini
+	}, []string{"method", "route", "status"})
+
+	llmRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "Latency of the Gemini calls by operation.",
+		Buckets:   []float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"operation"})
+
+	llmErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_errors_total",
+		Help:      "Failed Gemini calls by operation.",
+	}, []string{"operation"})
+
+	llmTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens reported in the Gemini usage metadata by operation and type (prompt, cached, output, thoughts).",
+	}, []string{"operation", "type"})
+
+	nextActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chat_next_actions_total",
+		Help:      "Outcomes of the triage chat replies by next_action.",
+	}, []string{"next_action"})
+
+	notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Emails sent through the relay by kind and outcome (success, failure).",
+	}, []string{"kind", "outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestDuration, llmRequestDuration, llmErrors, llmTokens, nextActions, notifications)
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
+
+// Middleware records the latency of every request by its route pattern, e.g. /session/:id,
+// so session and user IDs don't end up as labels
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		started := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(started).Seconds())
+	}
+}
+
+// ObserveLLMCall records the latency of a Gemini call and whether it failed
+func ObserveLLMCall(operation string, duration time.Duration, err error) {
+	llmRequestDuration.WithLabelValues(operation).Observe(duration.Seconds())
+	if err != nil {
+		llmErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// AddLLMTokens counts the tokens of a Gemini call, tokenType is prompt, cached, output or thoughts
+func AddLLMTokens(operation string, tokenType string, count int32) {
+	if count > 0 {
+		llmTokens.WithLabelValues(operation, tokenType).Add(float64(count))
+	}
+}
+
+// CountNextAction counts the next_action returned to the patient
+func CountNextAction(nextAction string) {
+	nextActions.WithLabelValues(nextAction).Inc()
+}
+
+// CountNotification counts an email by its kind, e.g. otp or queue, and whether the relay accepted it
+func CountNotification(kind string, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	notifications.WithLabelValues(kind, outcome).Inc()
+}
+
+// RegisterDBStats exports the connection pool stats, e.g. open, in use and wait counts
+func RegisterDBStats(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// RegisterQueueLength exports the number of patients waiting per doctor, lengths is called on every scrape
+func RegisterQueueLength(lengths func(ctx context.Context) (map[string]int, error)) {
+	prometheus.MustRegister(&queueLengthCollector{lengths: lengths})
+}
+
+var queueLengthDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "queue", "length"),
+	"Patients waiting today per doctor, not yet diagnosed, cancelled or transferred.",
+	[]string{"doctor_id"}, nil,
+)
+
+type queueLengthCollector struct {
+	lengths func(ctx context.Context) (map[string]int, error)
+}
+
+func (c *queueLengthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueLengthDesc
+}
+
+func (c *queueLengthCollector) Collect(ch chan<- prometheus.Metric) {
+	// a scrape carries no request context, its queries are traced on their own
+	lengths, err := c.lengths(context.Background())
+	if err != nil {
+		slog.Error("Error collecting queue lengths", "error", err)
+		ch <- prometheus.NewInvalidMetric(queueLengthDesc, err)
+		return
+	}
+	for doctorID, length := range lengths {
+		ch <- prometheus.MustNewConstMetric(queueLengthDesc, prometheus.GaugeValue, float64(length), doctorID)
+	}
+}
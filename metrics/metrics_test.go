@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestMiddlewareLabelsByRoutePattern(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/session/:id", func(c *gin.Context) { c.Status(200) })
+
+	for _, path := range []string{"/session/a", "/session/b", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if count := testutil.CollectAndCount(httpRequestDuration); count != 2 {
+		t.Errorf("got %d series, want one for /session/:id and one for unmatched", count)
+	}
+
+	var sample dto.Metric
+	if err := httpRequestDuration.WithLabelValues("GET", "/session/:id", "200").(prometheus.Histogram).Write(&sample); err != nil {
+		t.Fatal(err)
+	}
+	if got := sample.GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("/session/:id requests = %d, want 2", got)
+	}
+}
+
+func TestCountNotification(t *testing.T) {
+	CountNotification("otp", nil)
+	CountNotification("otp", errors.New("relay down"))
+	CountNotification("otp", errors.New("relay down"))
+
+	if got := testutil.ToFloat64(notifications.WithLabelValues("otp", "success")); got != 1 {
+		t.Errorf("successes = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(notifications.WithLabelValues("otp", "failure")); got != 2 {
+		t.Errorf("failures = %v, want 2", got)
+	}
+}
+
+func TestQueueLengthCollector(t *testing.T) {
+	collector := &queueLengthCollector{lengths: func(context.Context) (map[string]int, error) {
+		return map[string]int{"doctor-1": 3, "doctor-2": 0}, nil
+	}}
+
+	expected := `
+# HELP triana_queue_length Patients waiting today per doctor, not yet diagnosed, cancelled or transferred.
+# TYPE triana_queue_length gauge
+triana_queue_length{doctor_id="doctor-1"} 3
+triana_queue_length{doctor_id="doctor-2"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
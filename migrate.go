@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/migrations"
+)
+
+const migrateUsage = "usage: main migrate up | down [steps] | status"
+
+// runMigrate runs the migrate subcommand, e.g. `./main migrate down 1`
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(config.DB)
+		for _, version := range applied {
+			fmt.Printf("applied %d\n", version)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("database is up to date")
+		}
+		return err
+
+	case "down":
+		// roll back the latest migration unless told otherwise
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+
+		rolledBack, err := migrations.Down(config.DB, steps)
+		for _, version := range rolledBack {
+			fmt.Printf("rolled back %d\n", version)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrations.Status(config.DB)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+
+	return errors.New(migrateUsage)
+}
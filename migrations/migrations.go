@@ -0,0 +1,262 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock that keeps replicas from migrating at the same time
+const lockKey = 74017
+
+// fileName matches migration files such as 0002_add_doctor_language.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change with the SQL to apply and to roll it back
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration was applied and when, AppliedAt is nil for pending ones
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of the schema_migrations table
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"type:timestamp;not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load reads the embedded migrations ordered by version
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+
+		content, err := files.ReadFile("sql/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Up applies the pending migrations and returns the versions it applied
+func Up(db *gorm.DB) ([]int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int
+	err = withLock(db, func(conn *gorm.DB) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			// the change and its schema_migrations row are committed together
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration.Version)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the given number of applied migrations, latest first, and returns their versions
+func Down(db *gorm.DB, steps int) ([]int, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]Migration{}
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var rolledBack []int
+	err = withLock(db, func(conn *gorm.DB) error {
+		var rows []schemaMigration
+		if err := conn.Order("version DESC").Limit(steps).Find(&rows).Error; err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			migration, ok := byVersion[row.Version]
+			if !ok {
+				return fmt.Errorf("migration %d_%s is applied but unknown to this binary", row.Version, row.Name)
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, "version = ?", row.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			rolledBack = append(rolledBack, migration.Version)
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status lists the known migrations and the applied ones this binary doesn't know, ordered by version
+func Status(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	// status doesn't create the table, a fresh database simply has nothing applied
+	done := map[int]schemaMigration{}
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		if done, err = appliedVersions(db); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := done[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(done, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range done {
+		statuses = append(statuses, MigrationStatus{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+// Version returns the latest applied migration, 0 when none was applied yet
+func Version(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return 0, nil
+	}
+
+	var version int
+	err := db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Latest returns the version of the newest embedded migration
+func Latest() (int, error) {
+	migrations, err := Load()
+	if err != nil || len(migrations) == 0 {
+		return 0, err
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// withLock runs fn on a single connection holding the advisory lock, other replicas wait for it
+func withLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", lockKey)
+
+		if err := ensureTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+func ensureTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS "schema_migrations" (
+		"version" bigint PRIMARY KEY,
+		"name" varchar(255) NOT NULL,
+		"applied_at" timestamp NOT NULL
+	)`).Error
+}
+
+func appliedVersions(db *gorm.DB) (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	done := map[int]schemaMigration{}
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadPairsUpAndDownInOrder(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 || migrations[0].Name != "baseline" {
+		t.Fatalf("migrations = %+v, want the baseline first", migrations)
+	}
+
+	for i, migration := range migrations {
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d is out of order", migration.Version)
+		}
+		if strings.TrimSpace(migration.Up) == "" || strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %d_%s has an empty up or down file", migration.Version, migration.Name)
+		}
+	}
+
+	latest, err := Latest()
+	if err != nil || latest != migrations[len(migrations)-1].Version {
+		t.Errorf("Latest = %d, %v", latest, err)
+	}
+}
+
+func TestBaselineCreatesEveryTable(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tables := []string{
+		"users", "doctors", "sessions", "messages", "queues", "doctor_schedules", "appointments",
+		"queue_transfers", "consultation_notes", "consultation_diagnoses", "consultation_note_revisions",
+		"icd10_codes", "coded_diagnoses", "prediagnosis_evaluations", "prediagnosis_ratings", "drugs",
+		"prescriptions", "patient_allergies", "patient_profiles", "patient_conditions", "patient_medications",
+		"session_symptoms", "handoff_summaries",
+	}
+	for _, table := range tables {
+		if !strings.Contains(migrations[0].Up, `CREATE TABLE IF NOT EXISTS "`+table+`"`) {
+			t.Errorf("baseline does not create %s", table)
+		}
+		if !strings.Contains(migrations[0].Down, `DROP TABLE IF EXISTS "`+table+`"`) {
+			t.Errorf("baseline rollback does not drop %s", table)
+		}
+	}
+}
+
+// databases created by the former AutoMigrate already have these tables without the columns added since
+func TestBaselineAddsColumnsToAutoMigrateTables(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	columns := map[string][]string{
+		"doctors": {"language"},
+		"queues":  {"status", "arrived_at", "priority"},
+	}
+	for table, names := range columns {
+		for _, name := range names {
+			if !strings.Contains(migrations[0].Up, `ALTER TABLE "`+table+`" ADD COLUMN IF NOT EXISTS "`+name+`"`) {
+				t.Errorf("baseline does not add %s.%s to existing tables", table, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+DROP TABLE IF EXISTS "handoff_summaries";
+DROP TABLE IF EXISTS "session_symptoms";
+DROP TABLE IF EXISTS "patient_medications";
+DROP TABLE IF EXISTS "patient_conditions";
+DROP TABLE IF EXISTS "patient_profiles";
+DROP TABLE IF EXISTS "patient_allergies";
+DROP TABLE IF EXISTS "prescriptions";
+DROP TABLE IF EXISTS "drugs";
+DROP TABLE IF EXISTS "prediagnosis_ratings";
+DROP TABLE IF EXISTS "prediagnosis_evaluations";
+DROP TABLE IF EXISTS "coded_diagnoses";
+DROP TABLE IF EXISTS "icd10_codes";
+DROP TABLE IF EXISTS "consultation_note_revisions";
+DROP TABLE IF EXISTS "consultation_diagnoses";
+DROP TABLE IF EXISTS "consultation_notes";
+DROP TABLE IF EXISTS "queue_transfers";
+DROP TABLE IF EXISTS "appointments";
+DROP TABLE IF EXISTS "doctor_schedules";
+DROP TABLE IF EXISTS "queues";
+DROP TABLE IF EXISTS "messages";
+DROP TABLE IF EXISTS "sessions";
+DROP TABLE IF EXISTS "doctors";
+DROP TABLE IF EXISTS "users";
@@ -0,0 +1,335 @@
+-- Baseline of the schema AutoMigrate used to create. Every statement is guarded so databases
+-- created by AutoMigrate adopt the baseline, the columns added to their existing tables are
+-- created by the ALTER TABLE statements after them.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "email" varchar(100) NOT NULL,
+    "nationality" varchar(100) NOT NULL,
+    "dob" date NOT NULL,
+    "gender" varchar(10) NOT NULL,
+    "otp" varchar(6),
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+
+CREATE TABLE IF NOT EXISTS "doctors" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "email" varchar(100) NOT NULL,
+    "specialty" varchar(100) NOT NULL,
+    "roomno" varchar(10) NOT NULL,
+    "language" varchar(10) NOT NULL DEFAULT 'id',
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_doctors_email" UNIQUE ("email")
+);
+ALTER TABLE "doctors" ADD COLUMN IF NOT EXISTS "language" varchar(10) NOT NULL DEFAULT 'id';
+
+CREATE TABLE IF NOT EXISTS "sessions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "weight" float NOT NULL,
+    "height" float NOT NULL,
+    "heartrate" float NOT NULL,
+    "bodytemp" float NOT NULL,
+    "prediagnosis" varchar(100),
+    "doctor_diagnosis" varchar(100),
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_sessions" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "messages" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "role" varchar(50) NOT NULL,
+    "content" text NOT NULL,
+    "session_id" uuid NOT NULL,
+    "created_at" timestamp DEFAULT now(),
+    "updated_at" timestamp DEFAULT now(),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sessions_messages" FOREIGN KEY ("session_id") REFERENCES "sessions"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "queues" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "doctor_id" uuid NOT NULL,
+    "session_id" uuid NOT NULL,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    "number" integer NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'WAITING',
+    "arrived_at" timestamp,
+    "priority" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_queues_doctor" FOREIGN KEY ("doctor_id") REFERENCES "doctors"("id"),
+    CONSTRAINT "fk_queues_session" FOREIGN KEY ("session_id") REFERENCES "sessions"("id")
+);
+ALTER TABLE "queues" ADD COLUMN IF NOT EXISTS "status" varchar(20) NOT NULL DEFAULT 'WAITING';
+ALTER TABLE "queues" ADD COLUMN IF NOT EXISTS "arrived_at" timestamp;
+ALTER TABLE "queues" ADD COLUMN IF NOT EXISTS "priority" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS "doctor_schedules" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "doctor_id" uuid NOT NULL,
+    "weekday" integer NOT NULL,
+    "start_time" varchar(5) NOT NULL,
+    "end_time" varchar(5) NOT NULL,
+    "slot_minutes" integer NOT NULL DEFAULT 15,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_doctor_schedules_doctor" FOREIGN KEY ("doctor_id") REFERENCES "doctors"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_doctor_schedules_doctor_id" ON "doctor_schedules" ("doctor_id");
+
+CREATE TABLE IF NOT EXISTS "appointments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "doctor_id" uuid NOT NULL,
+    "session_id" uuid NOT NULL,
+    "starts_at" timestamp NOT NULL,
+    "ends_at" timestamp NOT NULL,
+    "status" varchar(20) NOT NULL DEFAULT 'BOOKED',
+    "queue_id" uuid,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_appointments_doctor" FOREIGN KEY ("doctor_id") REFERENCES "doctors"("id"),
+    CONSTRAINT "fk_appointments_session" FOREIGN KEY ("session_id") REFERENCES "sessions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_appointments_session_id" ON "appointments" ("session_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_appointments_doctor_slot" ON "appointments" ("doctor_id", "starts_at") WHERE status <> 'CANCELLED';
+
+CREATE TABLE IF NOT EXISTS "queue_transfers" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "from_queue_id" uuid NOT NULL,
+    "to_queue_id" uuid NOT NULL,
+    "from_doctor_id" uuid NOT NULL,
+    "to_doctor_id" uuid NOT NULL,
+    "original_number" integer NOT NULL,
+    "new_number" integer NOT NULL,
+    "priority" boolean NOT NULL DEFAULT false,
+    "reason" text NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_queue_transfers_from_doctor" FOREIGN KEY ("from_doctor_id") REFERENCES "doctors"("id"),
+    CONSTRAINT "fk_queue_transfers_to_doctor" FOREIGN KEY ("to_doctor_id") REFERENCES "doctors"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_queue_transfers_session_id" ON "queue_transfers" ("session_id");
+CREATE INDEX IF NOT EXISTS "idx_queue_transfers_from_doctor_id" ON "queue_transfers" ("from_doctor_id");
+CREATE INDEX IF NOT EXISTS "idx_queue_transfers_to_doctor_id" ON "queue_transfers" ("to_doctor_id");
+
+CREATE TABLE IF NOT EXISTS "consultation_notes" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "doctor_id" uuid NOT NULL,
+    "subjective" text,
+    "objective" text,
+    "assessment" text,
+    "plan" text,
+    "notes" text,
+    "version" integer NOT NULL DEFAULT 1,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_consultation_notes_doctor" FOREIGN KEY ("doctor_id") REFERENCES "doctors"("id"),
+    CONSTRAINT "fk_sessions_consultation_note" FOREIGN KEY ("session_id") REFERENCES "sessions"("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_consultation_notes_session_id" ON "consultation_notes" ("session_id");
+
+CREATE TABLE IF NOT EXISTS "consultation_diagnoses" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "note_id" uuid NOT NULL,
+    "description" varchar(255) NOT NULL,
+    "icd10_code" varchar(10),
+    "is_primary" boolean NOT NULL DEFAULT false,
+    "is_chronic" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_consultation_notes_diagnoses" FOREIGN KEY ("note_id") REFERENCES "consultation_notes"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_consultation_diagnoses_note_id" ON "consultation_diagnoses" ("note_id");
+
+CREATE TABLE IF NOT EXISTS "consultation_note_revisions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "note_id" uuid NOT NULL,
+    "version" integer NOT NULL,
+    "doctor_id" uuid NOT NULL,
+    "content" jsonb NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_consultation_note_revisions_note_id" ON "consultation_note_revisions" ("note_id");
+
+CREATE TABLE IF NOT EXISTS "icd10_codes" (
+    "code" varchar(10),
+    "title" varchar(255) NOT NULL,
+    "chapter" varchar(5) NOT NULL,
+    PRIMARY KEY ("code")
+);
+CREATE INDEX IF NOT EXISTS "idx_icd10_codes_chapter" ON "icd10_codes" ("chapter");
+
+CREATE TABLE IF NOT EXISTS "coded_diagnoses" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "source" varchar(10) NOT NULL,
+    "code" varchar(10) NOT NULL,
+    "rank" integer NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sessions_coded_diagnoses" FOREIGN KEY ("session_id") REFERENCES "sessions"("id"),
+    CONSTRAINT "fk_coded_diagnoses_icd10" FOREIGN KEY ("code") REFERENCES "icd10_codes"("code")
+);
+CREATE INDEX IF NOT EXISTS "idx_coded_diagnoses_session_id" ON "coded_diagnoses" ("session_id");
+CREATE INDEX IF NOT EXISTS "idx_coded_diagnoses_code" ON "coded_diagnoses" ("code");
+
+CREATE TABLE IF NOT EXISTS "prediagnosis_evaluations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "routed_doctor_id" uuid,
+    "specialty" varchar(100) NOT NULL,
+    "ai_code" varchar(10),
+    "doctor_code" varchar(10),
+    "coded" boolean NOT NULL,
+    "exact_match" boolean NOT NULL,
+    "candidate_match" boolean NOT NULL,
+    "chapter_match" boolean NOT NULL,
+    "routing_correct" boolean NOT NULL,
+    "rating" integer,
+    "diagnosed_at" timestamp NOT NULL,
+    "computed_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_prediagnosis_evaluations_session_id" ON "prediagnosis_evaluations" ("session_id");
+CREATE INDEX IF NOT EXISTS "idx_prediagnosis_evaluations_specialty" ON "prediagnosis_evaluations" ("specialty");
+CREATE INDEX IF NOT EXISTS "idx_prediagnosis_evaluations_diagnosed_at" ON "prediagnosis_evaluations" ("diagnosed_at");
+
+CREATE TABLE IF NOT EXISTS "prediagnosis_ratings" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "doctor_id" uuid,
+    "rating" integer NOT NULL,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_prediagnosis_ratings_session_id" ON "prediagnosis_ratings" ("session_id");
+
+CREATE TABLE IF NOT EXISTS "drugs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "generic_name" varchar(100) NOT NULL,
+    "form" varchar(50) NOT NULL,
+    "strength" varchar(50),
+    "drug_class" varchar(100),
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_drugs_name" ON "drugs" ("name");
+
+CREATE TABLE IF NOT EXISTS "prescriptions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "doctor_id" uuid NOT NULL,
+    "drug_id" uuid NOT NULL,
+    "dose" varchar(50) NOT NULL,
+    "route" varchar(30) NOT NULL,
+    "frequency" varchar(50) NOT NULL,
+    "duration_days" integer NOT NULL,
+    "notes" text,
+    "allergy_override" boolean NOT NULL DEFAULT false,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_prescriptions_doctor" FOREIGN KEY ("doctor_id") REFERENCES "doctors"("id"),
+    CONSTRAINT "fk_prescriptions_drug" FOREIGN KEY ("drug_id") REFERENCES "drugs"("id"),
+    CONSTRAINT "fk_sessions_prescriptions" FOREIGN KEY ("session_id") REFERENCES "sessions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_prescriptions_session_id" ON "prescriptions" ("session_id");
+
+-- the profile lists belong to the user, they exist before the patient fills in the profile itself
+CREATE TABLE IF NOT EXISTS "patient_allergies" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "substance" varchar(100) NOT NULL,
+    "reaction" varchar(255),
+    "severity" varchar(20),
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_patient_allergies_user_id" ON "patient_allergies" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "patient_profiles" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "pregnancy_status" varchar(20) NOT NULL DEFAULT 'unknown',
+    "pregnancy_weeks" integer,
+    "smoking_status" varchar(20) NOT NULL DEFAULT 'unknown',
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_patient_profiles_user_id" ON "patient_profiles" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "patient_conditions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "name" varchar(255) NOT NULL,
+    "icd10_code" varchar(10),
+    "source" varchar(10) NOT NULL,
+    "session_id" uuid,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_patient_conditions_user_id" ON "patient_conditions" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "patient_medications" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "dose" varchar(50),
+    "frequency" varchar(50),
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_patient_medications_user_id" ON "patient_medications" ("user_id");
+
+-- AutoMigrate tied the conditions and medications to a profile row, which rejected them for patients without one
+ALTER TABLE "patient_conditions" DROP CONSTRAINT IF EXISTS "fk_patient_profiles_chronic_conditions";
+ALTER TABLE "patient_medications" DROP CONSTRAINT IF EXISTS "fk_patient_profiles_medications";
+
+CREATE TABLE IF NOT EXISTS "session_symptoms" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "symptom" varchar(100) NOT NULL,
+    "onset" varchar(100),
+    "duration" varchar(100),
+    "severity" varchar(20),
+    "location" varchar(100),
+    "modifiers" text,
+    "position" integer NOT NULL,
+    "created_at" timestamp NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_sessions_symptoms" FOREIGN KEY ("session_id") REFERENCES "sessions"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_session_symptoms_session_id" ON "session_symptoms" ("session_id");
+
+CREATE TABLE IF NOT EXISTS "handoff_summaries" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "session_id" uuid NOT NULL,
+    "doctor_id" uuid NOT NULL,
+    "language" varchar(10) NOT NULL,
+    "chief_complaint" text,
+    "hpi" text,
+    "relevant_history" text,
+    "vitals" text,
+    "red_flags" jsonb,
+    "differential" jsonb,
+    "created_at" timestamp NOT NULL,
+    "updated_at" timestamp NOT NULL,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_handoff_summaries_session_id" ON "handoff_summaries" ("session_id");
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "idx_consultation_note_revisions_note_version";
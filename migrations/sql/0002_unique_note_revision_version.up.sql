@@ -0,0 +1,2 @@
+-- two saves of the same note must not record the same version
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_consultation_note_revisions_note_version" ON "consultation_note_revisions" ("note_id", "version");
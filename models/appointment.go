@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AppointmentStatusBooked    = "BOOKED"
+	AppointmentStatusCheckedIn = "CHECKED_IN"
+	AppointmentStatusCancelled = "CANCELLED"
+	AppointmentStatusNoShow    = "NO_SHOW"
+)
+
+// Appointment is a booked time slot on a future date, it becomes a queue entry when the patient checks in
+type Appointment struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	DoctorID  uuid.UUID  `json:"doctor_id" gorm:"type:uuid;not null;uniqueIndex:idx_appointments_doctor_slot,where:status <> 'CANCELLED'"`
+	Doctor    Doctor     `json:"doctor" gorm:"foreignKey:DoctorID"`
+	SessionID uuid.UUID  `json:"session_id" gorm:"type:uuid;not null;index"`
+	Session   Session    `json:"-" gorm:"foreignKey:SessionID"`
+	StartsAt  time.Time  `json:"starts_at" gorm:"type:timestamp;not null;uniqueIndex:idx_appointments_doctor_slot,where:status <> 'CANCELLED'"`
+	EndsAt    time.Time  `json:"ends_at" gorm:"type:timestamp;not null"`
+	Status    string     `json:"status" gorm:"type:varchar(20);not null;default:'BOOKED'"`
+	QueueID   *uuid.UUID `json:"queue_id" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"type:timestamp;not null"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DiagnosisSourceAI     = "AI"
+	DiagnosisSourceDoctor = "DOCTOR"
+)
+
+// CodedDiagnosis is an ICD-10 code attached to a session, either proposed by the LLM or chosen by the doctor
+type CodedDiagnosis struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID uuid.UUID `json:"session_id" gorm:"type:uuid;not null;index"`
+	Source    string    `json:"source" gorm:"type:varchar(10);not null"`
+	Code      string    `json:"code" gorm:"type:varchar(10);not null;index"`
+	ICD10     ICD10Code `json:"icd10" gorm:"foreignKey:Code;references:Code"`
+	Rank      int       `json:"rank" gorm:"type:int;not null"` // 1 is the most likely or the primary diagnosis
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ConsultationNote is the structured (SOAP) note a doctor writes for a session, one note per session
+type ConsultationNote struct {
+	ID         uuid.UUID               `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID  uuid.UUID               `json:"session_id" gorm:"type:uuid;not null;uniqueIndex"`
+	DoctorID   uuid.UUID               `json:"doctor_id" gorm:"type:uuid;not null"`
+	Doctor     Doctor                  `json:"doctor" gorm:"foreignKey:DoctorID"`
+	Subjective string                  `json:"subjective" gorm:"type:text"`
+	Objective  string                  `json:"objective" gorm:"type:text"`
+	Assessment string                  `json:"assessment" gorm:"type:text"`
+	Plan       string                  `json:"plan" gorm:"type:text"`
+	Notes      string                  `json:"notes" gorm:"type:text"`
+	Diagnoses  []ConsultationDiagnosis `json:"diagnoses" gorm:"foreignKey:NoteID"`
+	Version    int                     `json:"version" gorm:"type:int;not null;default:1"`
+	CreatedAt  time.Time               `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt  time.Time               `json:"updated_at" gorm:"type:timestamp;not null"`
+}
+
+type ConsultationDiagnosis struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	NoteID      uuid.UUID `json:"note_id" gorm:"type:uuid;not null;index"`
+	Description string    `json:"description" gorm:"type:varchar(255);not null"`
+	ICD10Code   string    `json:"icd10_code" gorm:"type:varchar(10)"`
+	IsPrimary   bool      `json:"is_primary" gorm:"not null;default:false"`
+	IsChronic   bool      `json:"is_chronic" gorm:"not null;default:false"`
+}
+
+// ConsultationNoteRevision is a snapshot of a note after each edit, the latest revision matches the note
+type ConsultationNoteRevision struct {
+	ID        uuid.UUID       `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	NoteID    uuid.UUID       `json:"note_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_consultation_note_revisions_note_version"`
+	Version   int             `json:"version" gorm:"type:int;not null;uniqueIndex:idx_consultation_note_revisions_note_version"`
+	DoctorID  uuid.UUID       `json:"doctor_id" gorm:"type:uuid;not null"`
+	Content   json.RawMessage `json:"content" gorm:"type:jsonb;not null"`
+	CreatedAt time.Time       `json:"created_at" gorm:"type:timestamp;not null"`
+}
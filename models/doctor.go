@@ -5,5 +5,6 @@ type Doctor struct {
 	Name      string `json:"name" gorm:"type:varchar(100);not null"`
 	Email     string `json:"email" gorm:"type:varchar(100);unique;not null"`
 	Specialty string `json:"specialty" gorm:"type:varchar(100);not null"`
-	Roomno 	 string `json:"roomno" gorm:"type:varchar(10);not null"`
+	Roomno    string `json:"roomno" gorm:"type:varchar(10);not null"`
+	Language  string `json:"language" gorm:"type:varchar(10);not null;default:id"` // language of the handoff summary, "id" or "en"
 }
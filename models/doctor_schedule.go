@@ -0,0 +1,14 @@
+package models
+
+import "github.com/google/uuid"
+
+// DoctorSchedule is a weekly practice window of a doctor, split into bookable slots
+type DoctorSchedule struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	DoctorID    uuid.UUID `json:"doctor_id" gorm:"type:uuid;not null;index"`
+	Doctor      Doctor    `json:"-" gorm:"foreignKey:DoctorID"`
+	Weekday     int       `json:"weekday" gorm:"type:int;not null"`           // 0 is Sunday
+	StartTime   string    `json:"start_time" gorm:"type:varchar(5);not null"` // HH:MM
+	EndTime     string    `json:"end_time" gorm:"type:varchar(5);not null"`   // HH:MM
+	SlotMinutes int       `json:"slot_minutes" gorm:"type:int;not null;default:15"`
+}
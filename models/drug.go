@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+// Drug is an entry of the local drug catalog, loaded from a CSV file
+type Drug struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name        string    `json:"name" gorm:"type:varchar(100);uniqueIndex;not null"`
+	GenericName string    `json:"generic_name" gorm:"type:varchar(100);not null"`
+	Form        string    `json:"form" gorm:"type:varchar(50);not null"`
+	Strength    string    `json:"strength" gorm:"type:varchar(50)"`
+	DrugClass   string    `json:"drug_class" gorm:"type:varchar(100)"` // used for allergy checks, e.g. "penicillin"
+}
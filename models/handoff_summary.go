@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HandoffSummary is the clinician-oriented summary of the chat generated for the doctor at appointment time
+type HandoffSummary struct {
+	ID              uuid.UUID               `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID       uuid.UUID               `json:"session_id" gorm:"type:uuid;not null;uniqueIndex"`
+	DoctorID        uuid.UUID               `json:"doctor_id" gorm:"type:uuid;not null"`
+	Language        string                  `json:"language" gorm:"type:varchar(10);not null"`
+	ChiefComplaint  string                  `json:"chief_complaint" gorm:"type:text"`
+	HPI             string                  `json:"hpi" gorm:"type:text"` // history of present illness
+	RelevantHistory string                  `json:"relevant_history" gorm:"type:text"`
+	Vitals          string                  `json:"vitals" gorm:"type:text"`
+	RedFlags        []string                `json:"red_flags" gorm:"type:jsonb;serializer:json"`
+	Differential    []DifferentialDiagnosis `json:"differential" gorm:"type:jsonb;serializer:json"`
+	CreatedAt       time.Time               `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt       time.Time               `json:"updated_at" gorm:"type:timestamp;not null"`
+}
+
+type DifferentialDiagnosis struct {
+	Diagnosis string `json:"diagnosis"`
+	Reasoning string `json:"reasoning"`
+}
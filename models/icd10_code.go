@@ -0,0 +1,8 @@
+package models
+
+// ICD10Code is an entry of the ICD-10 catalog, loaded from a CSV file
+type ICD10Code struct {
+	Code    string `json:"code" gorm:"type:varchar(10);primaryKey"`
+	Title   string `json:"title" gorm:"type:varchar(255);not null"`
+	Chapter string `json:"chapter" gorm:"type:varchar(5);not null;index"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PatientAllergy struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Substance string    `json:"substance" gorm:"type:varchar(100);not null"` // drug, drug class or other allergen
+	Reaction  string    `json:"reaction" gorm:"type:varchar(255)"`
+	Severity  string    `json:"severity" gorm:"type:varchar(20)"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp;not null"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PregnancyStatusUnknown     = "unknown"
+	PregnancyStatusNotPregnant = "not_pregnant"
+	PregnancyStatusPregnant    = "pregnant"
+
+	SmokingStatusUnknown = "unknown"
+	SmokingStatusNever   = "never"
+	SmokingStatusFormer  = "former"
+	SmokingStatusCurrent = "current"
+
+	ConditionSourcePatient = "PATIENT"
+	ConditionSourceDoctor  = "DOCTOR"
+)
+
+// PatientProfile is the medical background of a patient kept across sessions
+type PatientProfile struct {
+	ID                uuid.UUID           `json:"-" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID            uuid.UUID           `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	Allergies         []PatientAllergy    `json:"allergies" gorm:"foreignKey:UserID;references:UserID"`
+	ChronicConditions []PatientCondition  `json:"chronic_conditions" gorm:"foreignKey:UserID;references:UserID"`
+	Medications       []PatientMedication `json:"medications" gorm:"foreignKey:UserID;references:UserID"`
+	PregnancyStatus   string              `json:"pregnancy_status" gorm:"type:varchar(20);not null;default:unknown"`
+	PregnancyWeeks    *int                `json:"pregnancy_weeks" gorm:"type:int"`
+	SmokingStatus     string              `json:"smoking_status" gorm:"type:varchar(20);not null;default:unknown"`
+	CreatedAt         time.Time           `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt         time.Time           `json:"updated_at" gorm:"type:timestamp;not null"`
+}
+
+type PatientCondition struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name      string     `json:"name" gorm:"type:varchar(255);not null"`
+	ICD10Code string     `json:"icd10_code" gorm:"type:varchar(10)"`
+	Source    string     `json:"source" gorm:"type:varchar(10);not null"`
+	SessionID *uuid.UUID `json:"session_id" gorm:"type:uuid"` // session in which a doctor diagnosed the condition
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp;not null"`
+}
+
+type PatientMedication struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"type:varchar(100);not null"`
+	Dose      string    `json:"dose" gorm:"type:varchar(50)"`
+	Frequency string    `json:"frequency" gorm:"type:varchar(50)"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PrediagnosisEvaluation compares the AI prediagnosis and routing of a session with the doctor's diagnosis
+type PrediagnosisEvaluation struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID      uuid.UUID  `json:"session_id" gorm:"type:uuid;not null;uniqueIndex"`
+	RoutedDoctorID *uuid.UUID `json:"routed_doctor_id" gorm:"type:uuid"` // the doctor the LLM assigned the patient to
+	Specialty      string     `json:"specialty" gorm:"type:varchar(100);not null;index"`
+	AICode         string     `json:"ai_code" gorm:"type:varchar(10)"`     // most likely code proposed by the LLM
+	DoctorCode     string     `json:"doctor_code" gorm:"type:varchar(10)"` // primary code chosen by the doctor
+	Coded          bool       `json:"coded" gorm:"not null"`               // both sides have codes, the match fields are only meaningful then
+	ExactMatch     bool       `json:"exact_match" gorm:"not null"`
+	CandidateMatch bool       `json:"candidate_match" gorm:"not null"` // the doctor's code is one of the AI candidates
+	ChapterMatch   bool       `json:"chapter_match" gorm:"not null"`
+	RoutingCorrect bool       `json:"routing_correct" gorm:"not null"` // the patient was not transferred to another doctor
+	Rating         *int       `json:"rating" gorm:"type:int"`
+	DiagnosedAt    time.Time  `json:"diagnosed_at" gorm:"type:timestamp;not null;index"`
+	ComputedAt     time.Time  `json:"computed_at" gorm:"type:timestamp;not null"`
+}
+
+// PrediagnosisRating is the doctor's 1-5 rating of the AI prediagnosis of a session
+type PrediagnosisRating struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID uuid.UUID  `json:"session_id" gorm:"type:uuid;not null;uniqueIndex"`
+	DoctorID  *uuid.UUID `json:"doctor_id" gorm:"type:uuid"`
+	Rating    int        `json:"rating" gorm:"type:int;not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"type:timestamp;not null"`
+}
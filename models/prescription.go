@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Prescription struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID       uuid.UUID `json:"session_id" gorm:"type:uuid;not null;index"`
+	DoctorID        uuid.UUID `json:"doctor_id" gorm:"type:uuid;not null"`
+	Doctor          Doctor    `json:"-" gorm:"foreignKey:DoctorID"`
+	DrugID          uuid.UUID `json:"drug_id" gorm:"type:uuid;not null"`
+	Drug            Drug      `json:"drug" gorm:"foreignKey:DrugID"`
+	Dose            string    `json:"dose" gorm:"type:varchar(50);not null"`
+	Route           string    `json:"route" gorm:"type:varchar(30);not null"`
+	Frequency       string    `json:"frequency" gorm:"type:varchar(50);not null"`
+	DurationDays    int       `json:"duration_days" gorm:"type:int;not null"`
+	Notes           string    `json:"notes" gorm:"type:text"`
+	AllergyOverride bool      `json:"allergy_override" gorm:"not null;default:false"` // prescribed despite an allergy warning
+	CreatedAt       time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"type:timestamp;not null"`
+}
@@ -6,13 +6,23 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	QueueStatusWaiting     = "WAITING"
+	QueueStatusCancelled   = "CANCELLED"
+	QueueStatusNoShow      = "NO_SHOW"
+	QueueStatusTransferred = "TRANSFERRED"
+)
+
 type Queue struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	DoctorID  uuid.UUID `json:"doctor_id" gorm:"type:uuid;not null"`
-	Doctor    Doctor    `json:"doctor" gorm:"foreignKey:DoctorID"`
-	SessionID uuid.UUID `json:"session_id" gorm:"type:uuid;not null"`
-	Session   Session   `json:"session" gorm:"foreignKey:SessionID"`
-	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;not null"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp;not null"`
-	Number    int       `json:"number" gorm:"type:int;not null"`
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	DoctorID  uuid.UUID  `json:"doctor_id" gorm:"type:uuid;not null"`
+	Doctor    Doctor     `json:"doctor" gorm:"foreignKey:DoctorID"`
+	SessionID uuid.UUID  `json:"session_id" gorm:"type:uuid;not null"`
+	Session   Session    `json:"session" gorm:"foreignKey:SessionID"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp;not null"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"type:timestamp;not null"`
+	Number    int        `json:"number" gorm:"type:int;not null"`
+	Status    string     `json:"status" gorm:"type:varchar(20);not null;default:'WAITING'"`
+	ArrivedAt *time.Time `json:"arrived_at" gorm:"type:timestamp"`       // set when the patient checks in at the clinic
+	Priority  bool       `json:"priority" gorm:"not null;default:false"` // priority entries are called before the others
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QueueTransfer records a patient moved by a doctor to another doctor's queue, kept for routing analytics
+type QueueTransfer struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID      uuid.UUID `json:"session_id" gorm:"type:uuid;not null;index"`
+	FromQueueID    uuid.UUID `json:"from_queue_id" gorm:"type:uuid;not null"`
+	ToQueueID      uuid.UUID `json:"to_queue_id" gorm:"type:uuid;not null"`
+	FromDoctorID   uuid.UUID `json:"from_doctor_id" gorm:"type:uuid;not null;index"`
+	FromDoctor     Doctor    `json:"from_doctor" gorm:"foreignKey:FromDoctorID"`
+	ToDoctorID     uuid.UUID `json:"to_doctor_id" gorm:"type:uuid;not null;index"`
+	ToDoctor       Doctor    `json:"to_doctor" gorm:"foreignKey:ToDoctorID"`
+	OriginalNumber int       `json:"original_number" gorm:"type:int;not null"`
+	NewNumber      int       `json:"new_number" gorm:"type:int;not null"`
+	Priority       bool      `json:"priority" gorm:"not null;default:false"`
+	Reason         string    `json:"reason" gorm:"type:text;not null"`
+	CreatedAt      time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionSymptom is a symptom extracted from the chat transcript of a session
+type SessionSymptom struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	SessionID uuid.UUID `json:"session_id" gorm:"type:uuid;not null;index"`
+	Symptom   string    `json:"symptom" gorm:"type:varchar(100);not null"`
+	Onset     string    `json:"onset" gorm:"type:varchar(100)"`
+	Duration  string    `json:"duration" gorm:"type:varchar(100)"`
+	Severity  string    `json:"severity" gorm:"type:varchar(20)"`
+	Location  string    `json:"location" gorm:"type:varchar(100)"`
+	Modifiers string    `json:"modifiers" gorm:"type:text"` // what makes it better or worse, associated symptoms
+	Position  int       `json:"position" gorm:"type:int;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp;not null"`
+}
@@ -0,0 +1,51 @@
+package openapi
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the spec as JSON, it is built on the first request
+func Handler() gin.HandlerFunc {
+	spec := sync.OnceValues(func() ([]byte, error) {
+		return json.Marshal(Spec())
+	})
+
+	return func(c *gin.Context) {
+		body, err := spec()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Data(200, "application/json; charset=utf-8", body)
+	}
+}
+
+// DocsHandler serves Swagger UI for /openapi.json, its assets are loaded from a CDN
+func DocsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(200, "text/html; charset=utf-8", []byte(docsPage))
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Triana API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
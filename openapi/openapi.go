@@ -0,0 +1,265 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Document is the part of OpenAPI 3.0 the spec uses
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path by lowercase method, e.g. "get"
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string            `json:"tags,omitempty"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description,omitempty"`
+	OperationID string              `json:"operationId"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path" or "query"
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is a JSON schema, an empty one allows any value
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// datetimeFormats are the layouts of the datetime validations, e.g. datetime=15:04 for schedules
+var datetimeFormats = map[string]struct{ format, pattern string }{
+	"2006-01-02": {format: "date"},
+	"15:04":      {pattern: `^([01][0-9]|2[0-3]):[0-5][0-9]$`},
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// components collects the schemas of the named struct types, they are referenced by $ref
+type components struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newComponents() *components {
+	return &components{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// ref returns the schema of the type of v, e.g. schemas.OTPInput{}
+func (c *components) ref(v any) *Schema {
+	return c.schemaOf(reflect.TypeOf(v))
+}
+
+func (c *components) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := c.schemaOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: c.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: c.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return c.structSchema(t) // anonymous structs are inlined
+		}
+		return &Schema{Ref: "#/components/schemas/" + c.register(t)}
+	}
+	return &Schema{} // interfaces, e.g. the resource of a FHIR bundle entry
+}
+
+// register adds the schema of a named struct once, a type named like one of another package
+// is prefixed with its package, e.g. SchemasMessage next to the Message model
+func (c *components) register(t reflect.Type) string {
+	if name, ok := c.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := c.schemas[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	c.names[t] = name
+	c.schemas[name] = &Schema{} // placeholder for types that refer to themselves, e.g. User and Session
+	*c.schemas[name] = *c.structSchema(t)
+	return name
+}
+
+func (c *components) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	c.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON fields of t to schema, embedded structs are flattened like encoding/json does
+func (c *components) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			c.addFields(schema, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := c.schemaOf(field.Type)
+		if applyValidation(property, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyValidation translates the validator tags of a field, e.g. required,oneof=mild severe, and
+// tells whether the field is required
+func applyValidation(schema *Schema, tag string) (required bool) {
+	if tag == "" || schema.Ref != "" {
+		return strings.HasPrefix(tag, "required")
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			return required // the rules after dive apply to the items
+		case "required":
+			required = true
+		case "email", "uuid":
+			schema.Format = key
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "datetime":
+			if layout, ok := datetimeFormats[value]; ok {
+				schema.Format, schema.Pattern = layout.format, layout.pattern
+			}
+		case "min", "max":
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			setLimit(schema, key, limit)
+		}
+	}
+	return required
+}
+
+// setLimit sets min or max as the length of strings, the item count of arrays or the value of numbers
+func setLimit(schema *Schema, key string, limit int) {
+	switch schema.Type {
+	case "string":
+		if key == "min" {
+			schema.MinLength = &limit
+		} else {
+			schema.MaxLength = &limit
+		}
+	case "array":
+		if key == "min" {
+			schema.MinItems = &limit
+		}
+	case "integer", "number":
+		value := float64(limit)
+		if key == "min" {
+			schema.Minimum = &value
+		} else {
+			schema.Maximum = &value
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package openapi
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/schemas"
+)
+
+func TestSchemaFollowsValidateTags(t *testing.T) {
+	c := newComponents()
+	ref := c.ref(schemas.DoctorDiagnosisInput{})
+	if ref.Ref != "#/components/schemas/DoctorDiagnosisInput" {
+		t.Fatalf("ref = %q, want the DoctorDiagnosisInput component", ref.Ref)
+	}
+
+	schema := c.schemas["DoctorDiagnosisInput"]
+	if !slices.Contains(schema.Required, "diagnosis") || slices.Contains(schema.Required, "ai_rating") {
+		t.Errorf("required = %v, want diagnosis but not ai_rating", schema.Required)
+	}
+
+	rating := schema.Properties["ai_rating"]
+	if rating == nil || rating.Type != "integer" || !rating.Nullable {
+		t.Fatalf("ai_rating = %+v, want a nullable integer", rating)
+	}
+	if rating.Minimum == nil || *rating.Minimum != 1 || rating.Maximum == nil || *rating.Maximum != 5 {
+		t.Errorf("ai_rating bounds = %v..%v, want 1..5", rating.Minimum, rating.Maximum)
+	}
+}
+
+func TestApplyValidation(t *testing.T) {
+	email := &Schema{Type: "string"}
+	if !applyValidation(email, "required,email") || email.Format != "email" {
+		t.Errorf("required,email = %+v, want a required email", email)
+	}
+
+	severity := &Schema{Type: "string"}
+	applyValidation(severity, "oneof=mild moderate severe")
+	if !slices.Equal(severity.Enum, []string{"mild", "moderate", "severe"}) {
+		t.Errorf("enum = %v", severity.Enum)
+	}
+
+	// the rules after dive belong to the items
+	codes := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	applyValidation(codes, "min=1,dive,max=10")
+	if codes.MinItems == nil || *codes.MinItems != 1 || codes.Items.MaxLength != nil {
+		t.Errorf("min=1,dive,max=10 = %+v", codes)
+	}
+}
@@ -0,0 +1,364 @@
+package openapi
+
+import (
+	"strings"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+)
+
+// Spec documents every route registered in main, the request and response bodies are derived from
+// the schemas and models packages so they follow the code
+func Spec() *Document {
+	s := newSpecBuilder()
+	c := s.components
+
+	// ref types used by many responses
+	queue := nullable(c.ref(models.Queue{}))
+	appointment := nullable(c.ref(models.Appointment{}))
+	profile := nullable(c.ref(models.PatientProfile{}))
+	intakeSummary := nullable(c.ref(schemas.IntakeSummary{}))
+	handoffSummary := nullable(c.ref(models.HandoffSummary{}))
+
+	// users
+	s.add("POST", "/register", "RegisterUser", "Users", "Register a patient, or update them, and email a one-time password").
+		body(c.ref(schemas.RegisterUserInput{})).
+		ok(object(properties{
+			"message": str(),
+			"user":    object(properties{"id": uuidString(), "name": str(), "email": str()}),
+		}))
+	s.add("POST", "/verify-otp", "VerifyOTP", "Users", "Verify the one-time password and start a session with the vitals").
+		body(c.ref(schemas.OTPInput{})).
+		ok(object(properties{"message": str(), "session": c.ref(models.Session{})}))
+	s.add("GET", "/user/:id", "GetUserDetails", "Users", "Get a patient with their current session and history").
+		ok(c.ref(schemas.UserDetails{}))
+
+	// patient profile and allergies
+	s.add("GET", "/user/:id/profile", "GetPatientProfile", "Patients", "Get the medical profile of a patient").
+		ok(object(properties{"profile": profile}))
+	s.add("PUT", "/user/:id/profile", "UpdatePatientProfile", "Patients", "Replace the medical profile of a patient").
+		body(c.ref(schemas.PatientProfileInput{})).
+		ok(object(properties{"message": str(), "profile": profile}))
+	s.add("GET", "/user/:id/allergies", "GetPatientAllergies", "Patients", "List the allergies of a patient").
+		ok(object(properties{"allergies": arrayOf(c.ref(models.PatientAllergy{}))}))
+	s.add("POST", "/user/:id/allergies", "AddPatientAllergy", "Patients", "Add an allergy to a patient").
+		body(c.ref(schemas.AllergyInput{})).
+		ok(object(properties{"message": str(), "allergy": c.ref(models.PatientAllergy{})}))
+	s.add("DELETE", "/user/:id/allergies/:allergy_id", "DeletePatientAllergy", "Patients", "Delete an allergy of a patient").
+		ok(messageOnly())
+
+	// triage chat
+	s.add("GET", "/session/:id", "GetActiveSession", "Sessions", "Get a session while the triage chat is still going").
+		ok(c.ref(models.Session{}))
+	s.add("POST", "/session/:id", "GenerateSessionResponse", "Sessions", "Send a message to the triage assistant").
+		describe("When next_action is APPOINTMENT the patient gets a queue number for today, or an appointment when they asked for a later date.").
+		body(c.ref(schemas.SessionChatInput{})).
+		ok(object(properties{
+			"message":       str(),
+			"next_action":   enum("CONTINUE_CHAT", "APPOINTMENT"),
+			"reply":         str(),
+			"session_id":    uuidString(),
+			"queue":         queue,
+			"current_queue": queue,
+			"appointment":   appointment,
+		}))
+	s.add("GET", "/session/:id/symptoms", "GetSessionSymptoms", "Sessions", "Get the symptoms extracted from the chat").
+		ok(object(properties{"intake_summary": intakeSummary}))
+	s.add("POST", "/session/:id/symptoms/extract", "ExtractSessionSymptoms", "Sessions", "Extract the symptoms of the chat again").
+		ok(object(properties{"message": str(), "intake_summary": intakeSummary}))
+	s.add("GET", "/session/:id/handoff", "GetHandoffSummary", "Sessions", "Get the handoff summary for the doctor").
+		ok(object(properties{"handoff_summary": c.ref(models.HandoffSummary{})}))
+	s.add("POST", "/session/:id/handoff", "GenerateHandoffSummary", "Sessions", "Write the handoff summary again in the language of a doctor").
+		body(c.ref(schemas.HandoffSummaryInput{})).
+		ok(object(properties{"message": str(), "handoff_summary": c.ref(models.HandoffSummary{})}))
+	s.add("POST", "/session/:id/appointment", "BookAppointment", "Appointments", "Book a slot of a doctor for the session").
+		body(c.ref(schemas.BookAppointmentInput{})).
+		ok(object(properties{"message": str(), "appointment": c.ref(models.Appointment{})}))
+
+	// consultation
+	s.add("POST", "/session/:id/diagnose", "DoctorDiagnose", "Consultation", "Save the diagnosis of the doctor").
+		body(c.ref(schemas.DoctorDiagnosisInput{})).
+		ok(messageOnly())
+	s.add("GET", "/session/:id/note", "GetConsultationNote", "Consultation", "Get the consultation note of a session").
+		ok(object(properties{"consultation_note": c.ref(models.ConsultationNote{})}))
+	s.add("PUT", "/session/:id/note", "SaveConsultationNote", "Consultation", "Create or edit the consultation note, every save is kept as a revision").
+		body(c.ref(schemas.ConsultationNoteInput{})).
+		ok(object(properties{"message": str(), "consultation_note": c.ref(models.ConsultationNote{})}))
+	s.add("GET", "/session/:id/note/history", "GetConsultationNoteHistory", "Consultation", "List the revisions of the consultation note").
+		ok(object(properties{"revisions": arrayOf(c.ref(models.ConsultationNoteRevision{}))}))
+	s.add("GET", "/session/:id/prescriptions", "GetPrescriptions", "Prescriptions", "List the prescriptions of a session").
+		ok(object(properties{"prescriptions": arrayOf(c.ref(models.Prescription{}))}))
+	s.add("POST", "/session/:id/prescriptions", "CreatePrescriptions", "Prescriptions", "Prescribe drugs, checked against the allergies of the patient").
+		describe("Answers 409 allergy_conflict with the allergy_conflicts in the details unless override_allergy is set.").
+		body(c.ref(schemas.PrescriptionInput{})).
+		ok(object(properties{
+			"message":           str(),
+			"prescriptions":     arrayOf(c.ref(models.Prescription{})),
+			"allergy_conflicts": arrayOf(c.ref(schemas.AllergyConflict{})),
+		}))
+	s.add("GET", "/session/:id/summary", "GetVisitSummary", "Consultation", "Get the visit summary for the patient").
+		ok(c.ref(schemas.VisitSummary{}))
+	s.add("GET", "/session/:id/summary/pdf", "GetVisitSummaryPDF", "Consultation", "Download the visit summary as PDF").
+		file("application/pdf")
+	s.add("GET", "/session/:id/summary/html", "GetVisitSummaryHTML", "Consultation", "Render the visit summary as HTML").
+		file("text/html")
+	s.add("POST", "/session/:id/summary/email", "EmailVisitSummary", "Consultation", "Email the visit summary to the patient").
+		ok(messageOnly())
+
+	// queue
+	token := queryParam("token", "Signed token of the link in the email", str(), true)
+	s.add("GET", "/queue/:doctor_id", "GetCurrentQueue", "Queue", "Get the patient the doctor sees next").
+		ok(object(properties{
+			"queue":           c.ref(models.Queue{}),
+			"patient_profile": profile,
+			"intake_summary":  intakeSummary,
+			"handoff_summary": handoffSummary,
+		}))
+	s.add("GET", "/queue/entry/:id/calendar", "GetQueueCalendar", "Queue", "Download the calendar invitation of a queue entry").
+		file("text/calendar")
+	s.add("POST", "/queue/entry/:id/cancel", "CancelQueue", "Queue", "Cancel a queue entry from the link in the email").
+		params(token).
+		ok(object(properties{"message": str(), "queue_id": uuidString(), "status": str(), "current_queue": queue}))
+	s.add("POST", "/queue/entry/:id/reschedule", "RescheduleQueue", "Queue", "Move a queue entry to another doctor today or to an appointment on a later date").
+		params(token).
+		body(c.ref(schemas.RescheduleInput{})).
+		ok(object(properties{"message": str(), "queue": queue, "current_queue": queue, "appointment": appointment}))
+	s.add("POST", "/queue/entry/:id/check-in", "CheckInQueue", "Queue", "Check in at the clinic with the QR code of the queue email").
+		params(token).
+		ok(object(properties{
+			"message":       str(),
+			"queue_id":      uuidString(),
+			"number":        &Schema{Type: "integer"},
+			"arrived_at":    &Schema{Type: "string", Format: "date-time"},
+			"room":          str(),
+			"current_queue": queue,
+		}))
+	s.add("GET", "/queue/entry/:id/qr", "GetCheckInQRCode", "Queue", "Get the check-in QR code of a queue entry").
+		params(token).
+		file("image/png")
+	s.add("POST", "/queue/entry/:id/transfer", "TransferQueue", "Queue", "Transfer a waiting patient to another doctor").
+		body(c.ref(schemas.TransferQueueInput{})).
+		ok(object(properties{"message": str(), "queue": c.ref(models.Queue{})}))
+
+	// doctors
+	s.add("GET", "/doctor/:id", "GetDoctorDetails", "Doctors", "Get a doctor with their appointment counts and current patient").
+		ok(object(properties{
+			"doctor":                     c.ref(models.Doctor{}),
+			"appointment_count_all_time": &Schema{Type: "integer"},
+			"appointment_count_daily":    &Schema{Type: "integer"},
+			"current_queue":              queue,
+			"patient_profile":            profile,
+			"intake_summary":             intakeSummary,
+			"handoff_summary":            handoffSummary,
+		}))
+	s.add("GET", "/doctor/:id/schedules", "GetDoctorSchedules", "Doctors", "Get the weekly schedule of a doctor").
+		ok(object(properties{"schedules": arrayOf(c.ref(models.DoctorSchedule{}))}))
+	s.add("PUT", "/doctor/:id/schedules", "UpdateDoctorSchedules", "Doctors", "Replace the weekly schedule of a doctor").
+		body(c.ref(schemas.DoctorScheduleInput{})).
+		ok(object(properties{"message": str(), "schedules": arrayOf(c.ref(models.DoctorSchedule{}))}))
+	s.add("GET", "/doctor/:id/slots", "GetAvailableSlots", "Doctors", "List the free slots of a doctor on a date").
+		params(queryParam("date", "YYYY-MM-DD, today by default", &Schema{Type: "string", Format: "date"}, false)).
+		ok(object(properties{"date": &Schema{Type: "string", Format: "date"}, "slots": arrayOf(c.ref(schemas.TimeSlot{}))}))
+
+	// appointments
+	s.add("POST", "/appointment/:id/check-in", "CheckInAppointment", "Appointments", "Turn a booked appointment into a queue entry on arrival").
+		ok(object(properties{"message": str(), "queue": c.ref(models.Queue{}), "current_queue": queue}))
+	s.add("GET", "/appointment/:id/calendar", "GetAppointmentCalendar", "Appointments", "Download the calendar invitation of an appointment").
+		file("text/calendar")
+	s.add("POST", "/appointment/:id/cancel", "CancelAppointment", "Appointments", "Cancel an appointment from the link in the email").
+		params(token).
+		ok(object(properties{"message": str(), "appointment_id": uuidString(), "status": str()}))
+
+	// catalogs and reports
+	search := queryParam("q", "Code or text to search", str(), true)
+	limit := queryParam("limit", "1 to 100, 20 by default", &Schema{Type: "integer"}, false)
+	s.add("GET", "/icd10", "SearchICD10", "Catalogs", "Search the ICD-10 catalog").
+		params(search, limit).
+		ok(object(properties{"codes": arrayOf(c.ref(models.ICD10Code{}))}))
+	s.add("GET", "/drugs", "SearchDrugs", "Catalogs", "Search the drug catalog").
+		params(search, limit).
+		ok(object(properties{"drugs": arrayOf(c.ref(models.Drug{}))}))
+	s.add("GET", "/reports/prediagnosis-accuracy", "GetPrediagnosisAccuracyReport", "Reports", "Compare the AI prediagnoses with the diagnoses of the doctors").
+		params(
+			queryParam("from", "YYYY-MM-DD, 30 days before to by default", &Schema{Type: "string", Format: "date"}, false),
+			queryParam("to", "YYYY-MM-DD inclusive, today by default", &Schema{Type: "string", Format: "date"}, false),
+			queryParam("period", "Grouping of by_period, week by default", enum("day", "week", "month"), false),
+		).
+		ok(c.ref(schemas.AccuracyReport{}))
+
+	// FHIR R4 export
+	s.add("GET", "/fhir/Patient/:id", "GetFHIRPatient", "FHIR", "Export a patient as a FHIR Patient").
+		fhir(c.ref(schemas.FHIRPatient{}))
+	s.add("GET", "/fhir/Patient/:id/$everything", "GetFHIRPatientEverything", "FHIR", "Export a patient with their encounters, observations, conditions and appointments").
+		fhir(c.ref(schemas.FHIRBundle{}))
+	s.add("GET", "/fhir/Encounter", "SearchFHIREncounters", "FHIR", "Search the encounters of a patient").
+		params(queryParam("patient", "Patient ID, either <id> or Patient/<id>", str(), true)).
+		fhir(c.ref(schemas.FHIRBundle{}))
+
+	// operations
+	s.add("GET", "/healthz", "Healthz", "Operations", "Liveness probe").
+		ok(object(properties{"status": str()}))
+	s.add("GET", "/readyz", "Readyz", "Operations", "Readiness probe, 503 when a required dependency is down").
+		ok(c.ref(schemas.HealthReport{})).
+		response("503", "A required dependency is down", "application/json", c.ref(schemas.HealthReport{}))
+	s.add("GET", "/metrics", "Metrics", "Operations", "Prometheus metrics").
+		file("text/plain")
+	s.add("GET", "/ping", "Ping", "Operations", "Answers pong").
+		file("text/plain")
+	s.add("GET", "/openapi.json", "OpenAPI", "Operations", "This document").
+		ok(&Schema{Type: "object"})
+	s.add("GET", "/docs", "Docs", "Operations", "The API docs").
+		file("text/html")
+
+	return s.doc
+}
+
+type specBuilder struct {
+	doc        *Document
+	components *components
+}
+
+func newSpecBuilder() *specBuilder {
+	c := newComponents()
+	return &specBuilder{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info: Info{
+				Title:       "Triana API",
+				Description: "Triage chat, queue, appointments and consultation for the clinic.",
+				Version:     "1.0.0",
+			},
+			Paths:      map[string]PathItem{},
+			Components: Components{Schemas: c.schemas},
+		},
+		components: c,
+	}
+}
+
+// add documents a route, path is in the Gin syntax, e.g. /session/:id, its parameters are UUIDs
+// and every error answers with a schemas.ErrorResponse
+func (s *specBuilder) add(method string, path string, operationID string, tag string, summary string) *operationBuilder {
+	operation := &Operation{
+		Tags:        []string{tag},
+		Summary:     summary,
+		OperationID: operationID,
+		Responses: map[string]Response{
+			"default": {Description: "Error", Content: jsonContent(s.components.ref(schemas.ErrorResponse{}))},
+		},
+	}
+
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			operation.Parameters = append(operation.Parameters, Parameter{Name: name, In: "path", Required: true, Schema: uuidString()})
+		}
+	}
+
+	openAPIPath := PathFromGin(path)
+	if s.doc.Paths[openAPIPath] == nil {
+		s.doc.Paths[openAPIPath] = PathItem{}
+	}
+	s.doc.Paths[openAPIPath][strings.ToLower(method)] = operation
+	return &operationBuilder{operation: operation, components: s.components}
+}
+
+// PathFromGin turns a Gin route path into an OpenAPI one, e.g. /session/:id into /session/{id}
+func PathFromGin(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Operation returns the operation of a Gin route, nil when the route is not documented
+func (d *Document) Operation(method string, ginPath string) *Operation {
+	return d.Paths[PathFromGin(ginPath)][strings.ToLower(method)]
+}
+
+func queryParam(name string, description string, schema *Schema, required bool) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Required: required, Schema: schema}
+}
+
+type operationBuilder struct {
+	operation  *Operation
+	components *components
+}
+
+func (b *operationBuilder) describe(description string) *operationBuilder {
+	b.operation.Description = description
+	return b
+}
+
+func (b *operationBuilder) params(parameters ...Parameter) *operationBuilder {
+	b.operation.Parameters = append(b.operation.Parameters, parameters...)
+	return b
+}
+
+func (b *operationBuilder) body(schema *Schema) *operationBuilder {
+	b.operation.RequestBody = &RequestBody{Required: true, Content: jsonContent(schema)}
+	return b
+}
+
+func (b *operationBuilder) ok(schema *Schema) *operationBuilder {
+	return b.response("200", "OK", "application/json", schema)
+}
+
+// file documents a response that is not JSON, e.g. a PDF or a calendar invitation
+func (b *operationBuilder) file(contentType string) *operationBuilder {
+	schema := &Schema{Type: "string"}
+	if !strings.HasPrefix(contentType, "text/") {
+		schema.Format = "binary"
+	}
+	return b.response("200", "OK", contentType, schema)
+}
+
+// fhir documents a FHIR resource response, errors are an OperationOutcome instead of the usual body
+func (b *operationBuilder) fhir(schema *Schema) *operationBuilder {
+	b.response("200", "OK", "application/fhir+json", schema)
+	outcome := b.operation.Responses["default"]
+	outcome.Content = map[string]MediaType{"application/fhir+json": {Schema: b.components.ref(schemas.FHIROperationOutcome{})}}
+	b.operation.Responses["default"] = outcome
+	return b
+}
+
+func (b *operationBuilder) response(status string, description string, contentType string, schema *Schema) *operationBuilder {
+	b.operation.Responses[status] = Response{Description: description, Content: map[string]MediaType{contentType: {Schema: schema}}}
+	return b
+}
+
+type properties map[string]*Schema
+
+func object(props properties) *Schema {
+	return &Schema{Type: "object", Properties: props}
+}
+
+func arrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+func str() *Schema {
+	return &Schema{Type: "string"}
+}
+
+func uuidString() *Schema {
+	return &Schema{Type: "string", Format: "uuid"}
+}
+
+func enum(values ...string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+func messageOnly() *Schema {
+	return object(properties{"message": str()})
+}
+
+// nullable marks a referenced schema as nullable, OpenAPI 3.0 ignores the siblings of a $ref
+func nullable(ref *Schema) *Schema {
+	return &Schema{AllOf: []*Schema{ref}, Nullable: true}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
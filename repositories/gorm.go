@@ -0,0 +1,305 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewGormRepositories returns the repositories backed by the database
+func NewGormRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:    NewGormUserRepository(db),
+		Sessions: NewGormSessionRepository(db),
+		Messages: NewGormMessageRepository(db),
+		Queues:   NewGormQueueRepository(db),
+		Doctors:  NewGormDoctorRepository(db),
+	}
+}
+
+// notFound maps gorm's missing record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+func (r *gormUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUserRepository) Save(ctx context.Context, user *models.User) error {
+	// the user's sessions are saved through their own repository
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(user).Error
+}
+
+type gormSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSessionRepository(db *gorm.DB) SessionRepository {
+	return &gormSessionRepository{db: db}
+}
+
+// withRecords preloads what the doctor wrote down during the visit
+func withRecords(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("ConsultationNote.Doctor").
+		Preload("ConsultationNote.Diagnoses").
+		Preload("CodedDiagnoses.ICD10").
+		Preload("Prescriptions.Drug")
+}
+
+func (r *gormSessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := withRecords(r.db.WithContext(ctx)).
+		Preload("User").
+		Preload("Messages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC") // earlier messages first
+		}).
+		Preload("Symptoms", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &session, nil
+}
+
+func (r *gormSessionRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := withRecords(r.db.WithContext(ctx)).Where("user_id = ?", userID).Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *gormSessionRepository) FindHistory(ctx context.Context, userID uuid.UUID, excludeID uuid.UUID) ([]models.Session, error) {
+	var history []models.Session
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Where("id != ?", excludeID).Order("created_at DESC").Find(&history).Error
+	return history, err
+}
+
+func (r *gormSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *gormSessionRepository) Save(ctx context.Context, session *models.Session) error {
+	// preloaded messages and records are saved through their own services
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(session).Error
+}
+
+type gormMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMessageRepository(db *gorm.DB) MessageRepository {
+	return &gormMessageRepository{db: db}
+}
+
+func (r *gormMessageRepository) Create(ctx context.Context, message *models.Message) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+type gormQueueRepository struct {
+	db *gorm.DB
+}
+
+func NewGormQueueRepository(db *gorm.DB) QueueRepository {
+	return &gormQueueRepository{db: db}
+}
+
+func (r *gormQueueRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	var queue models.Queue
+	if err := r.db.WithContext(ctx).Preload("Doctor").Where("id = ?", id).First(&queue).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &queue, nil
+}
+
+func (r *gormQueueRepository) FindWithPatient(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	var queue models.Queue
+	err := r.db.WithContext(ctx).Preload("Doctor").Preload("Session.User").Where("id = ?", id).First(&queue).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &queue, nil
+}
+
+func (r *gormQueueRepository) FindForUpdate(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	var queue models.Queue
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&queue).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &queue, nil
+}
+
+func (r *gormQueueRepository) FindLatestBySession(ctx context.Context, sessionID uuid.UUID) (*models.Queue, error) {
+	var queue models.Queue
+	err := r.db.WithContext(ctx).Where("session_id = ?", sessionID).Order("created_at DESC").First(&queue).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &queue, nil
+}
+
+func (r *gormQueueRepository) LatestNumber(ctx context.Context, doctorID uuid.UUID, since time.Time) (int, error) {
+	var latestQueue models.Queue
+	err := r.db.WithContext(ctx).
+		Where("doctor_id = ?", doctorID).
+		Where("created_at >= ?", since).
+		Order("number DESC").
+		First(&latestQueue).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return latestQueue.Number, nil
+}
+
+func (r *gormQueueRepository) FindNext(ctx context.Context, doctorID uuid.UUID, since time.Time) (*models.Queue, error) {
+	var queue models.Queue
+	err := r.db.WithContext(ctx).
+		Joins("JOIN sessions ON sessions.id = queues.session_id").
+		Where("queues.doctor_id = ?", doctorID).
+		Where("queues.created_at >= ?", since).
+		Where("sessions.doctor_diagnosis = ''").
+		Where("queues.status = ?", models.QueueStatusWaiting).
+		Where("queues.arrived_at IS NOT NULL").
+		Order("queues.priority DESC").
+		Order("queues.number ASC").
+		Preload("Session").
+		First(&queue).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &queue, nil
+}
+
+func (r *gormQueueRepository) Count(ctx context.Context, doctorID uuid.UUID, since time.Time) (int, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&models.Queue{}).Where("doctor_id = ?", doctorID)
+	if !since.IsZero() {
+		query = query.Where("created_at >= ?", since)
+	}
+	err := query.Count(&count).Error
+	return int(count), err
+}
+
+func (r *gormQueueRepository) WaitingLengths(ctx context.Context, since time.Time) (map[string]int, error) {
+	var doctors []models.Doctor
+	if err := r.db.WithContext(ctx).Select("id").Find(&doctors).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		DoctorID string
+		Length   int
+	}
+	err := r.db.WithContext(ctx).Model(&models.Queue{}).
+		Select("queues.doctor_id, COUNT(*) AS length").
+		Joins("JOIN sessions ON sessions.id = queues.session_id").
+		Where("queues.created_at >= ?", since).
+		Where("queues.status = ?", models.QueueStatusWaiting).
+		Where("sessions.doctor_diagnosis = ''").
+		Group("queues.doctor_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lengths := map[string]int{}
+	for _, doctor := range doctors {
+		lengths[doctor.ID] = 0
+	}
+	for _, row := range rows {
+		lengths[row.DoctorID] = row.Length
+	}
+	return lengths, nil
+}
+
+func (r *gormQueueRepository) Create(ctx context.Context, queue *models.Queue) error {
+	return r.db.WithContext(ctx).Create(queue).Error
+}
+
+func (r *gormQueueRepository) Save(ctx context.Context, queue *models.Queue) error {
+	// the preloaded doctor and session are not changed through the queue
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(queue).Error
+}
+
+func (r *gormQueueRepository) FindTransfers(ctx context.Context, sessionID uuid.UUID) ([]models.QueueTransfer, error) {
+	var transfers []models.QueueTransfer
+	err := r.db.WithContext(ctx).Preload("FromDoctor").Preload("ToDoctor").
+		Where("session_id = ?", sessionID).Order("created_at ASC").Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *gormQueueRepository) CreateTransfer(ctx context.Context, transfer *models.QueueTransfer) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(transfer).Error
+}
+
+func (r *gormQueueRepository) Transaction(ctx context.Context, fn func(queues QueueRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&gormQueueRepository{db: tx})
+	})
+}
+
+type gormDoctorRepository struct {
+	db *gorm.DB
+}
+
+func NewGormDoctorRepository(db *gorm.DB) DoctorRepository {
+	return &gormDoctorRepository{db: db}
+}
+
+func (r *gormDoctorRepository) FindAll(ctx context.Context) ([]models.Doctor, error) {
+	var doctors []models.Doctor
+	err := r.db.WithContext(ctx).Find(&doctors).Error
+	return doctors, err
+}
+
+func (r *gormDoctorRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Doctor, error) {
+	var doctor models.Doctor
+	if err := r.db.WithContext(ctx).First(&doctor, "id = ?", id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &doctor, nil
+}
+
+func (r *gormDoctorRepository) FindSchedules(ctx context.Context) ([]models.DoctorSchedule, error) {
+	var schedules []models.DoctorSchedule
+	err := r.db.WithContext(ctx).Order("weekday ASC, start_time ASC").Find(&schedules).Error
+	return schedules, err
+}
@@ -0,0 +1,462 @@
+package repositories
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps the records of the in-memory repositories, the repositories share it
+// so sessions can be returned with their user and messages like the database does
+type memoryStore struct {
+	mu        sync.RWMutex
+	tx        sync.Mutex // transactions run one at a time, like they would hold the same row locks
+	users     map[uuid.UUID]models.User
+	sessions  map[uuid.UUID]models.Session
+	messages  []models.Message
+	queues    map[uuid.UUID]models.Queue
+	transfers []models.QueueTransfer
+	doctors   []models.Doctor
+	schedules []models.DoctorSchedule
+}
+
+// NewMemoryRepositories returns repositories that keep everything in memory, doctors are managed
+// outside the API so they are given up front
+func NewMemoryRepositories(doctors ...models.Doctor) Repositories {
+	return NewMemoryRepositoriesWithSchedules(doctors, nil)
+}
+
+// NewMemoryRepositoriesWithSchedules is NewMemoryRepositories with the practice schedules of the doctors
+func NewMemoryRepositoriesWithSchedules(doctors []models.Doctor, schedules []models.DoctorSchedule) Repositories {
+	store := &memoryStore{
+		users:    map[uuid.UUID]models.User{},
+		sessions: map[uuid.UUID]models.Session{},
+		queues:   map[uuid.UUID]models.Queue{},
+	}
+	for _, doctor := range doctors {
+		if doctor.ID == "" {
+			doctor.ID = uuid.NewString()
+		}
+		store.doctors = append(store.doctors, doctor)
+	}
+	for _, schedule := range schedules {
+		newID(&schedule.ID)
+		store.schedules = append(store.schedules, schedule)
+	}
+
+	return Repositories{
+		Users:    &memoryUserRepository{store},
+		Sessions: &memorySessionRepository{store},
+		Messages: &memoryMessageRepository{store},
+		Queues:   &memoryQueueRepository{store},
+		Doctors:  &memoryDoctorRepository{store},
+	}
+}
+
+// newID assigns the ID the database would generate
+func newID(id *uuid.UUID) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+}
+
+type memoryUserRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryUserRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	newID(&user.ID)
+	stored := *user
+	stored.Sessions = nil
+	r.store.users[user.ID] = stored
+	return nil
+}
+
+func (r *memoryUserRepository) Save(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := *user
+	stored.Sessions = nil
+	r.store.users[user.ID] = stored
+	return nil
+}
+
+type memorySessionRepository struct {
+	store *memoryStore
+}
+
+func (r *memorySessionRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	session.User = r.store.users[session.UserID]
+	for _, message := range r.store.messages {
+		if message.SessionID == id {
+			session.Messages = append(session.Messages, message)
+		}
+	}
+	sort.SliceStable(session.Messages, func(i, j int) bool {
+		return session.Messages[i].CreatedAt.Before(session.Messages[j].CreatedAt)
+	})
+	return &session, nil
+}
+
+func (r *memorySessionRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	return r.find(func(session models.Session) bool {
+		return session.UserID == userID
+	}, false), nil
+}
+
+func (r *memorySessionRepository) FindHistory(ctx context.Context, userID uuid.UUID, excludeID uuid.UUID) ([]models.Session, error) {
+	return r.find(func(session models.Session) bool {
+		return session.UserID == userID && session.ID != excludeID
+	}, true), nil
+}
+
+// find returns the sessions matching the filter ordered by creation time
+func (r *memorySessionRepository) find(match func(models.Session) bool, newestFirst bool) []models.Session {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, session := range r.store.sessions {
+		if match(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if newestFirst {
+			return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+		}
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
+func (r *memorySessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	newID(&session.ID)
+	r.store.sessions[session.ID] = storedSession(*session)
+	return nil
+}
+
+func (r *memorySessionRepository) Save(ctx context.Context, session *models.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.sessions[session.ID] = storedSession(*session)
+	return nil
+}
+
+// storedSession drops the associations, they are kept by their own repositories
+func storedSession(session models.Session) models.Session {
+	session.User = models.User{}
+	session.Messages = nil
+	session.ConsultationNote = nil
+	session.CodedDiagnoses = nil
+	session.Prescriptions = nil
+	session.Symptoms = nil
+	return session
+}
+
+type memoryMessageRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryMessageRepository) Create(ctx context.Context, message *models.Message) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	newID(&message.ID)
+	stored := *message
+	stored.Session = models.Session{}
+	r.store.messages = append(r.store.messages, stored)
+	return nil
+}
+
+type memoryQueueRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryQueueRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	queue, ok := r.store.queues[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	queue.Doctor = r.store.doctor(queue.DoctorID)
+	return &queue, nil
+}
+
+func (r *memoryQueueRepository) FindWithPatient(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	queue, err := r.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	queue.Session = r.store.sessions[queue.SessionID]
+	queue.Session.User = r.store.users[queue.Session.UserID]
+	return queue, nil
+}
+
+func (r *memoryQueueRepository) FindForUpdate(ctx context.Context, id uuid.UUID) (*models.Queue, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	queue, ok := r.store.queues[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &queue, nil
+}
+
+func (r *memoryQueueRepository) FindLatestBySession(ctx context.Context, sessionID uuid.UUID) (*models.Queue, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var latest *models.Queue
+	for _, queue := range r.store.queues {
+		if queue.SessionID == sessionID && (latest == nil || queue.CreatedAt.After(latest.CreatedAt)) {
+			latest = &queue
+		}
+	}
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+	return latest, nil
+}
+
+// doctor returns the doctor with the given ID, or an empty doctor like a missing preload
+func (s *memoryStore) doctor(id uuid.UUID) models.Doctor {
+	for _, doctor := range s.doctors {
+		if doctor.ID == id.String() {
+			return doctor
+		}
+	}
+	return models.Doctor{}
+}
+
+func (r *memoryQueueRepository) LatestNumber(ctx context.Context, doctorID uuid.UUID, since time.Time) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	latest := 0
+	for _, queue := range r.store.queues {
+		if queue.DoctorID == doctorID && !queue.CreatedAt.Before(since) && queue.Number > latest {
+			latest = queue.Number
+		}
+	}
+	return latest, nil
+}
+
+func (r *memoryQueueRepository) FindNext(ctx context.Context, doctorID uuid.UUID, since time.Time) (*models.Queue, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var next *models.Queue
+	for _, queue := range r.store.queues {
+		session := r.store.sessions[queue.SessionID]
+		if queue.DoctorID != doctorID || queue.CreatedAt.Before(since) || session.DoctorDiagnosis != "" ||
+			queue.Status != models.QueueStatusWaiting || queue.ArrivedAt == nil {
+			continue
+		}
+
+		// priority entries first, then by number
+		if next == nil || (queue.Priority && !next.Priority) ||
+			(queue.Priority == next.Priority && queue.Number < next.Number) {
+			queue.Session = session
+			next = &queue
+		}
+	}
+	if next == nil {
+		return nil, ErrNotFound
+	}
+	return next, nil
+}
+
+func (r *memoryQueueRepository) Count(ctx context.Context, doctorID uuid.UUID, since time.Time) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	count := 0
+	for _, queue := range r.store.queues {
+		if queue.DoctorID == doctorID && !queue.CreatedAt.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryQueueRepository) WaitingLengths(ctx context.Context, since time.Time) (map[string]int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	lengths := map[string]int{}
+	for _, doctor := range r.store.doctors {
+		lengths[doctor.ID] = 0
+	}
+	for _, queue := range r.store.queues {
+		if queue.CreatedAt.Before(since) || queue.Status != models.QueueStatusWaiting ||
+			r.store.sessions[queue.SessionID].DoctorDiagnosis != "" {
+			continue
+		}
+		lengths[queue.DoctorID.String()]++
+	}
+	return lengths, nil
+}
+
+func (r *memoryQueueRepository) Create(ctx context.Context, queue *models.Queue) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	newID(&queue.ID)
+	r.store.queues[queue.ID] = storedQueue(*queue)
+	return nil
+}
+
+func (r *memoryQueueRepository) Save(ctx context.Context, queue *models.Queue) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.queues[queue.ID] = storedQueue(*queue)
+	return nil
+}
+
+// storedQueue drops the doctor and the session, they are kept by their own repositories
+func storedQueue(queue models.Queue) models.Queue {
+	queue.Doctor = models.Doctor{}
+	queue.Session = models.Session{}
+	return queue
+}
+
+func (r *memoryQueueRepository) FindTransfers(ctx context.Context, sessionID uuid.UUID) ([]models.QueueTransfer, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	transfers := []models.QueueTransfer{}
+	for _, transfer := range r.store.transfers {
+		if transfer.SessionID == sessionID {
+			transfer.FromDoctor = r.store.doctor(transfer.FromDoctorID)
+			transfer.ToDoctor = r.store.doctor(transfer.ToDoctorID)
+			transfers = append(transfers, transfer)
+		}
+	}
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].CreatedAt.Before(transfers[j].CreatedAt)
+	})
+	return transfers, nil
+}
+
+func (r *memoryQueueRepository) CreateTransfer(ctx context.Context, transfer *models.QueueTransfer) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	newID(&transfer.ID)
+	stored := *transfer
+	stored.FromDoctor = models.Doctor{}
+	stored.ToDoctor = models.Doctor{}
+	r.store.transfers = append(r.store.transfers, stored)
+	return nil
+}
+
+// Transaction restores the queue entries and transfers when fn fails
+func (r *memoryQueueRepository) Transaction(ctx context.Context, fn func(queues QueueRepository) error) error {
+	r.store.tx.Lock()
+	defer r.store.tx.Unlock()
+
+	r.store.mu.RLock()
+	queues := maps.Clone(r.store.queues)
+	transfers := slices.Clone(r.store.transfers)
+	r.store.mu.RUnlock()
+
+	if err := fn(r); err != nil {
+		r.store.mu.Lock()
+		r.store.queues = queues
+		r.store.transfers = transfers
+		r.store.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+type memoryDoctorRepository struct {
+	store *memoryStore
+}
+
+func (r *memoryDoctorRepository) FindAll(ctx context.Context) ([]models.Doctor, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return append([]models.Doctor{}, r.store.doctors...), nil
+}
+
+func (r *memoryDoctorRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.Doctor, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, doctor := range r.store.doctors {
+		if doctor.ID == id.String() {
+			return &doctor, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryDoctorRepository) FindSchedules(ctx context.Context) ([]models.DoctorSchedule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	schedules := append([]models.DoctorSchedule{}, r.store.schedules...)
+	sort.SliceStable(schedules, func(i, j int) bool {
+		if schedules[i].Weekday != schedules[j].Weekday {
+			return schedules[i].Weekday < schedules[j].Weekday
+		}
+		return schedules[i].StartTime < schedules[j].StartTime
+	})
+	return schedules, nil
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned by every repository when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+type UserRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	Save(ctx context.Context, user *models.User) error
+}
+
+type SessionRepository interface {
+	// FindByID returns the session with its user, its messages in chat order and the doctor's records
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Session, error)
+	// FindByUserID returns all sessions of the user with the doctor's records
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	// FindHistory returns the other sessions of the user, newest first
+	FindHistory(ctx context.Context, userID uuid.UUID, excludeID uuid.UUID) ([]models.Session, error)
+	Create(ctx context.Context, session *models.Session) error
+	Save(ctx context.Context, session *models.Session) error
+}
+
+type MessageRepository interface {
+	Create(ctx context.Context, message *models.Message) error
+}
+
+type QueueRepository interface {
+	// FindByID returns the queue entry with its doctor
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Queue, error)
+	// FindWithPatient returns the queue entry with its doctor and the user of its session
+	FindWithPatient(ctx context.Context, id uuid.UUID) (*models.Queue, error)
+	// FindForUpdate returns the queue entry locked until the end of the transaction it is called in
+	FindForUpdate(ctx context.Context, id uuid.UUID) (*models.Queue, error)
+	// FindLatestBySession returns the newest queue entry of the session
+	FindLatestBySession(ctx context.Context, sessionID uuid.UUID) (*models.Queue, error)
+	// LatestNumber returns the highest number the doctor handed out since the given time, 0 if none
+	LatestNumber(ctx context.Context, doctorID uuid.UUID, since time.Time) (int, error)
+	// FindNext returns the waiting, checked-in entry the doctor calls next with its session
+	FindNext(ctx context.Context, doctorID uuid.UUID, since time.Time) (*models.Queue, error)
+	// Count returns the number of entries of the doctor since the given time, a zero time counts all
+	Count(ctx context.Context, doctorID uuid.UUID, since time.Time) (int, error)
+	// WaitingLengths counts the waiting entries since the given time whose session is not diagnosed yet
+	// per doctor ID, every doctor is included
+	WaitingLengths(ctx context.Context, since time.Time) (map[string]int, error)
+	Create(ctx context.Context, queue *models.Queue) error
+	Save(ctx context.Context, queue *models.Queue) error
+	// FindTransfers returns the transfers of the session with both doctors, oldest first
+	FindTransfers(ctx context.Context, sessionID uuid.UUID) ([]models.QueueTransfer, error)
+	CreateTransfer(ctx context.Context, transfer *models.QueueTransfer) error
+	// Transaction runs fn with a repository whose changes are all rolled back when fn returns an error
+	Transaction(ctx context.Context, fn func(queues QueueRepository) error) error
+}
+
+type DoctorRepository interface {
+	FindAll(ctx context.Context) ([]models.Doctor, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Doctor, error)
+	// FindSchedules returns the schedules of all doctors ordered by weekday and start time
+	FindSchedules(ctx context.Context) ([]models.DoctorSchedule, error)
+}
+
+// Repositories groups the repositories backed by the same store
+type Repositories struct {
+	Users    UserRepository
+	Sessions SessionRepository
+	Messages MessageRepository
+	Queues   QueueRepository
+	Doctors  DoctorRepository
+}
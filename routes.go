@@ -0,0 +1,110 @@
+package main
+
+import (
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/controllers"
+	"github.com/BeeCodingAI/triana-api/logging"
+	"github.com/BeeCodingAI/triana-api/metrics"
+	"github.com/BeeCodingAI/triana-api/openapi"
+	"github.com/BeeCodingAI/triana-api/tracing"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter registers the middlewares and every route, each route must be documented in openapi.Spec
+func newRouter(cfg *config.Config, ctl *controllers.Controller) *gin.Engine {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+
+	// the access log runs inside the trace so its lines carry the trace ID
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(cors.New(corsConfig))
+	r.Use(tracing.Middleware(cfg.Tracing.ServiceName))
+	r.Use(logging.Middleware())
+	r.Use(metrics.Middleware())
+	r.Use(controllers.ErrorHandler()) // innermost, so the others see the status of the error response
+
+	// register routes
+	r.POST("/register", ctl.RegisterUser)
+	r.POST("/verify-otp", ctl.VerifyOTP)
+
+	// session routes
+	r.GET("/session/:id", ctl.GetActiveSession)
+	r.POST("/session/:id", ctl.GenerateSessionResponse)
+	r.POST("/session/:id/diagnose", ctl.DoctorDiagnose)
+	r.GET("/session/:id/note", ctl.GetConsultationNote)
+	r.PUT("/session/:id/note", ctl.SaveConsultationNote)
+	r.GET("/session/:id/note/history", ctl.GetConsultationNoteHistory)
+	r.POST("/session/:id/appointment", ctl.BookAppointment)
+	r.GET("/session/:id/prescriptions", ctl.GetPrescriptions)
+	r.POST("/session/:id/prescriptions", ctl.CreatePrescriptions)
+	r.GET("/session/:id/summary", ctl.GetVisitSummary)
+	r.GET("/session/:id/summary/pdf", ctl.GetVisitSummaryPDF)
+	r.GET("/session/:id/summary/html", ctl.GetVisitSummaryHTML)
+	r.POST("/session/:id/summary/email", ctl.EmailVisitSummary)
+	r.GET("/session/:id/symptoms", ctl.GetSessionSymptoms)
+	r.POST("/session/:id/symptoms/extract", ctl.ExtractSessionSymptoms)
+	r.GET("/session/:id/handoff", ctl.GetHandoffSummary)
+	r.POST("/session/:id/handoff", ctl.GenerateHandoffSummary)
+
+	// queue routes
+	r.GET("/queue/:doctor_id", ctl.GetCurrentQueue)
+	r.GET("/queue/entry/:id/calendar", ctl.GetQueueCalendar)
+	r.POST("/queue/entry/:id/cancel", ctl.CancelQueue)
+	r.POST("/queue/entry/:id/reschedule", ctl.RescheduleQueue)
+	r.POST("/queue/entry/:id/check-in", ctl.CheckInQueue)
+	r.GET("/queue/entry/:id/qr", ctl.GetCheckInQRCode)
+	r.POST("/queue/entry/:id/transfer", ctl.TransferQueue)
+
+	// doctor routes
+	r.GET("/doctor/:id", ctl.GetDoctorDetails)
+	r.GET("/doctor/:id/schedules", ctl.GetDoctorSchedules)
+	r.PUT("/doctor/:id/schedules", ctl.UpdateDoctorSchedules)
+	r.GET("/doctor/:id/slots", ctl.GetAvailableSlots)
+
+	// appointment routes
+	r.POST("/appointment/:id/check-in", ctl.CheckInAppointment)
+	r.GET("/appointment/:id/calendar", ctl.GetAppointmentCalendar)
+	r.POST("/appointment/:id/cancel", ctl.CancelAppointment)
+
+	// ICD-10 routes
+	r.GET("/icd10", ctl.SearchICD10)
+
+	// drug catalog routes
+	r.GET("/drugs", ctl.SearchDrugs)
+
+	// report routes
+	r.GET("/reports/prediagnosis-accuracy", ctl.GetPrediagnosisAccuracyReport)
+
+	// FHIR R4 export routes
+	r.GET("/fhir/Patient/:id", ctl.GetFHIRPatient)
+	r.GET("/fhir/Patient/:id/$everything", ctl.GetFHIRPatientEverything)
+	r.GET("/fhir/Encounter", ctl.SearchFHIREncounters)
+
+	// user routes
+	r.GET("/user/:id", ctl.GetUserDetails)
+	r.GET("/user/:id/profile", ctl.GetPatientProfile)
+	r.PUT("/user/:id/profile", ctl.UpdatePatientProfile)
+	r.GET("/user/:id/allergies", ctl.GetPatientAllergies)
+	r.POST("/user/:id/allergies", ctl.AddPatientAllergy)
+	r.DELETE("/user/:id/allergies/:allergy_id", ctl.DeletePatientAllergy)
+
+	// health routes for the orchestrator, /healthz is liveness and /readyz checks the dependencies
+	r.GET("/healthz", ctl.Healthz)
+	r.GET("/readyz", ctl.Readyz)
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", metrics.Handler())
+
+	// OpenAPI 3 spec and the docs UI that renders it
+	r.GET("/openapi.json", openapi.Handler())
+	r.GET("/docs", openapi.DocsHandler())
+
+	// test routes
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(200, "pong")
+	})
+
+	return r
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/controllers"
+	"github.com/BeeCodingAI/triana-api/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Defaults()
+	return newRouter(cfg, controllers.NewController(cfg, nil, nil, nil, nil, nil))
+}
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	spec := openapi.Spec()
+	registered := map[string]bool{}
+
+	for _, route := range testRouter().Routes() {
+		registered[route.Method+" "+openapi.PathFromGin(route.Path)] = true
+		if spec.Operation(route.Method, route.Path) == nil {
+			t.Errorf("%s %s is missing from the OpenAPI spec", route.Method, route.Path)
+		}
+	}
+
+	// the spec must not document routes that are gone
+	for path, item := range spec.Paths {
+		for method := range item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestServeOpenAPISpec(t *testing.T) {
+	w := httptest.NewRecorder()
+	testRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != 200 {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	var document openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	if !strings.HasPrefix(document.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want a 3.x version", document.OpenAPI)
+	}
+
+	// every $ref must point to a registered schema
+	for _, ref := range strings.Split(w.Body.String(), `"$ref":"#/components/schemas/`)[1:] {
+		name, _, _ := strings.Cut(ref, `"`)
+		if document.Components.Schemas[name] == nil {
+			t.Errorf("$ref to unknown schema %q", name)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package schemas
+
+import "time"
+
+type AccuracyReport struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Period      string          `json:"period"`
+	Overall     AccuracyStats   `json:"overall"`
+	BySpecialty []AccuracyGroup `json:"by_specialty"`
+	ByPeriod    []AccuracyGroup `json:"by_period"`
+}
+
+// AccuracyStats aggregates prediagnosis evaluations, rates are null when there is nothing to compare
+type AccuracyStats struct {
+	Sessions           int64    `json:"sessions"`
+	CodedSessions      int64    `json:"coded_sessions"`
+	ExactMatchRate     *float64 `json:"exact_match_rate"`
+	CandidateMatchRate *float64 `json:"candidate_match_rate"`
+	ChapterMatchRate   *float64 `json:"chapter_match_rate"`
+	RoutedSessions     int64    `json:"routed_sessions"`
+	RoutingCorrectRate *float64 `json:"routing_correct_rate"`
+	RatedSessions      int64    `json:"rated_sessions"`
+	AverageRating      *float64 `json:"average_rating"`
+}
+
+type AccuracyGroup struct {
+	Key string `json:"key"`
+	AccuracyStats
+}
@@ -0,0 +1,19 @@
+package schemas
+
+import "time"
+
+type BookAppointmentInput struct {
+	DoctorID string    `json:"doctor_id" validate:"required,uuid"`
+	StartsAt time.Time `json:"starts_at" validate:"required"`
+}
+
+type DoctorScheduleInput struct {
+	Schedules []ScheduleInput `json:"schedules" validate:"required,dive"`
+}
+
+type ScheduleInput struct {
+	Weekday     int    `json:"weekday" validate:"min=0,max=6"`
+	StartTime   string `json:"start_time" validate:"required,datetime=15:04"`
+	EndTime     string `json:"end_time" validate:"required,datetime=15:04"`
+	SlotMinutes int    `json:"slot_minutes" validate:"required,min=5,max=240"`
+}
@@ -0,0 +1,19 @@
+package schemas
+
+type ConsultationNoteInput struct {
+	DoctorID   string           `json:"doctor_id" validate:"required,uuid"`
+	Subjective string           `json:"subjective"`
+	Objective  string           `json:"objective"`
+	Assessment string           `json:"assessment"`
+	Plan       string           `json:"plan"`
+	Notes      string           `json:"notes"`
+	Diagnoses  []DiagnosisInput `json:"diagnoses" validate:"required,min=1,dive"`
+	AIRating   *int             `json:"ai_rating" validate:"omitempty,min=1,max=5"` // rating of the AI prediagnosis
+}
+
+type DiagnosisInput struct {
+	Description string `json:"description" validate:"required,max=255"`
+	ICD10Code   string `json:"icd10_code" validate:"omitempty,max=10"`
+	IsPrimary   bool   `json:"is_primary"`
+	IsChronic   bool   `json:"is_chronic"` // added to the patient's chronic conditions
+}
@@ -0,0 +1,7 @@
+package schemas
+
+type DoctorDiagnosisInput struct {
+	Diagnosis  string   `json:"diagnosis" validate:"required"`
+	ICD10Codes []string `json:"icd10_codes"`
+	AIRating   *int     `json:"ai_rating" validate:"omitempty,min=1,max=5"` // rating of the AI prediagnosis
+}
@@ -1,11 +1,19 @@
 package schemas
 
 type Email struct {
-	To      string   `json:"to" binding:"required,email"`
-	From    string   `json:"from_email,omitempty" binding:"omitempty,email"`
-	Subject string   `json:"subject" binding:"required"`
-	Body    string   `json:"body" binding:"required"`
-	HTML    string   `json:"html,omitempty"`
-	CC      []string `json:"cc,omitempty"`
-	BCC     []string `json:"bcc,omitempty"`
+	To          string            `json:"to" binding:"required,email"`
+	From        string            `json:"from_email,omitempty" binding:"omitempty,email"`
+	Subject     string            `json:"subject" binding:"required"`
+	Body        string            `json:"body" binding:"required"`
+	HTML        string            `json:"html,omitempty"`
+	CC          []string          `json:"cc,omitempty"`
+	BCC         []string          `json:"bcc,omitempty"`
+	Attachments []EmailAttachment `json:"attachments,omitempty"`
+}
+
+// EmailAttachment is a file sent along with an email, Content is base64 encoded
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	Content     string `json:"content"`
+	ContentType string `json:"content_type"`
 }
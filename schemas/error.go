@@ -0,0 +1,10 @@
+package schemas
+
+// ErrorResponse is the body of every error response, Code is stable for clients to switch on,
+// e.g. invalid_otp or slot_unavailable, while Message may change
+type ErrorResponse struct {
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Details   map[string]any `json:"details,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
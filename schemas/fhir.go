@@ -0,0 +1,177 @@
+package schemas
+
+// FHIR R4 resources, limited to the elements we export
+
+type FHIRResource interface {
+	GetResourceType() string
+	GetID() string
+}
+
+type FHIRReference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type FHIRCoding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type FHIRCodeableConcept struct {
+	Coding []FHIRCoding `json:"coding,omitempty"`
+	Text   string       `json:"text,omitempty"`
+}
+
+type FHIRIdentifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type FHIRPeriod struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type FHIRQuantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+type FHIRExtension struct {
+	URL                  string               `json:"url"`
+	Extension            []FHIRExtension      `json:"extension,omitempty"`
+	ValueCodeableConcept *FHIRCodeableConcept `json:"valueCodeableConcept,omitempty"`
+}
+
+type FHIRHumanName struct {
+	Text string `json:"text,omitempty"`
+}
+
+type FHIRContactPoint struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type FHIRPatient struct {
+	ResourceType string             `json:"resourceType"`
+	ID           string             `json:"id"`
+	Extension    []FHIRExtension    `json:"extension,omitempty"`
+	Identifier   []FHIRIdentifier   `json:"identifier,omitempty"`
+	Name         []FHIRHumanName    `json:"name,omitempty"`
+	Telecom      []FHIRContactPoint `json:"telecom,omitempty"`
+	Gender       string             `json:"gender,omitempty"`
+	BirthDate    string             `json:"birthDate,omitempty"`
+}
+
+func (r FHIRPatient) GetResourceType() string { return r.ResourceType }
+func (r FHIRPatient) GetID() string           { return r.ID }
+
+type FHIREncounterParticipant struct {
+	Individual *FHIRReference `json:"individual,omitempty"`
+}
+
+type FHIREncounterDiagnosis struct {
+	Condition FHIRReference `json:"condition"`
+	Rank      int           `json:"rank,omitempty"`
+}
+
+type FHIREncounter struct {
+	ResourceType string                     `json:"resourceType"`
+	ID           string                     `json:"id"`
+	Status       string                     `json:"status"`
+	Class        FHIRCoding                 `json:"class"`
+	ServiceType  *FHIRCodeableConcept       `json:"serviceType,omitempty"`
+	Subject      *FHIRReference             `json:"subject,omitempty"`
+	Participant  []FHIREncounterParticipant `json:"participant,omitempty"`
+	Period       *FHIRPeriod                `json:"period,omitempty"`
+	ReasonCode   []FHIRCodeableConcept      `json:"reasonCode,omitempty"`
+	Diagnosis    []FHIREncounterDiagnosis   `json:"diagnosis,omitempty"`
+}
+
+func (r FHIREncounter) GetResourceType() string { return r.ResourceType }
+func (r FHIREncounter) GetID() string           { return r.ID }
+
+type FHIRObservation struct {
+	ResourceType      string                `json:"resourceType"`
+	ID                string                `json:"id"`
+	Status            string                `json:"status"`
+	Category          []FHIRCodeableConcept `json:"category,omitempty"`
+	Code              FHIRCodeableConcept   `json:"code"`
+	Subject           *FHIRReference        `json:"subject,omitempty"`
+	Encounter         *FHIRReference        `json:"encounter,omitempty"`
+	EffectiveDateTime string                `json:"effectiveDateTime,omitempty"`
+	ValueQuantity     *FHIRQuantity         `json:"valueQuantity,omitempty"`
+}
+
+func (r FHIRObservation) GetResourceType() string { return r.ResourceType }
+func (r FHIRObservation) GetID() string           { return r.ID }
+
+type FHIRCondition struct {
+	ResourceType       string                `json:"resourceType"`
+	ID                 string                `json:"id"`
+	ClinicalStatus     *FHIRCodeableConcept  `json:"clinicalStatus,omitempty"`
+	VerificationStatus *FHIRCodeableConcept  `json:"verificationStatus,omitempty"`
+	Category           []FHIRCodeableConcept `json:"category,omitempty"`
+	Code               *FHIRCodeableConcept  `json:"code,omitempty"`
+	Subject            FHIRReference         `json:"subject"`
+	Encounter          *FHIRReference        `json:"encounter,omitempty"`
+	RecordedDate       string                `json:"recordedDate,omitempty"`
+}
+
+func (r FHIRCondition) GetResourceType() string { return r.ResourceType }
+func (r FHIRCondition) GetID() string           { return r.ID }
+
+type FHIRAppointmentParticipant struct {
+	Actor  *FHIRReference `json:"actor,omitempty"`
+	Status string         `json:"status"`
+}
+
+type FHIRAppointment struct {
+	ResourceType string                       `json:"resourceType"`
+	ID           string                       `json:"id"`
+	Status       string                       `json:"status"`
+	ServiceType  []FHIRCodeableConcept        `json:"serviceType,omitempty"`
+	Description  string                       `json:"description,omitempty"`
+	Start        string                       `json:"start,omitempty"`
+	End          string                       `json:"end,omitempty"`
+	Created      string                       `json:"created,omitempty"`
+	Comment      string                       `json:"comment,omitempty"`
+	Participant  []FHIRAppointmentParticipant `json:"participant"`
+}
+
+func (r FHIRAppointment) GetResourceType() string { return r.ResourceType }
+func (r FHIRAppointment) GetID() string           { return r.ID }
+
+type FHIRBundleEntry struct {
+	FullURL  string       `json:"fullUrl,omitempty"`
+	Resource FHIRResource `json:"resource"`
+}
+
+type FHIRBundle struct {
+	ResourceType string            `json:"resourceType"`
+	ID           string            `json:"id,omitempty"`
+	Type         string            `json:"type"`
+	Timestamp    string            `json:"timestamp,omitempty"`
+	Total        *int              `json:"total,omitempty"`
+	Entry        []FHIRBundleEntry `json:"entry,omitempty"`
+}
+
+func (r FHIRBundle) GetResourceType() string { return r.ResourceType }
+func (r FHIRBundle) GetID() string           { return r.ID }
+
+type FHIROperationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+type FHIROperationOutcome struct {
+	ResourceType string                      `json:"resourceType"`
+	Issue        []FHIROperationOutcomeIssue `json:"issue"`
+}
+
+func (r FHIROperationOutcome) GetResourceType() string { return r.ResourceType }
+func (r FHIROperationOutcome) GetID() string           { return "" }
@@ -0,0 +1,18 @@
+package schemas
+
+// HandoffSummaryOutput is the output of the handoff summary LLM call
+type HandoffSummaryOutput struct {
+	ChiefComplaint  string   `json:"chief_complaint"`
+	HPI             string   `json:"hpi"`
+	RelevantHistory string   `json:"relevant_history"`
+	RedFlags        []string `json:"red_flags"`
+	Differential    []struct {
+		Diagnosis string `json:"diagnosis"`
+		Reasoning string `json:"reasoning"`
+	} `json:"differential"`
+}
+
+// HandoffSummaryInput asks for a new handoff summary in the language of the doctor
+type HandoffSummaryInput struct {
+	DoctorID string `json:"doctor_id" validate:"required,uuid"`
+}
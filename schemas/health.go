@@ -0,0 +1,18 @@
+package schemas
+
+import "time"
+
+// HealthReport is the body of /readyz, Status is "ok", "degraded" when an optional dependency fails
+// or "unavailable" when a required one does
+type HealthReport struct {
+	Status string                      `json:"status"`
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+type DependencyStatus struct {
+	Status    string    `json:"status"` // "up" or "down"
+	Required  bool      `json:"required"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
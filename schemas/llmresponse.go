@@ -1,8 +1,10 @@
 package schemas
 
 type LLMResponse struct {
-	NextAction string `json:"next_action"`
-	Reply      string `json:"reply"`
-	DoctorID  string `json:"doctor_id"`
-	PreDiagnosis string `json:"prediagnosis"`
-}
\ No newline at end of file
+	NextAction      string   `json:"next_action"`
+	Reply           string   `json:"reply"`
+	DoctorID        string   `json:"doctor_id"`
+	PreDiagnosis    string   `json:"prediagnosis"`
+	AppointmentDate string   `json:"appointment_date"`
+	ICD10Codes      []string `json:"icd10_codes"`
+}
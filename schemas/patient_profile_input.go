@@ -0,0 +1,22 @@
+package schemas
+
+// PatientProfileInput replaces the whole medical profile of a patient
+type PatientProfileInput struct {
+	Allergies         []AllergyInput    `json:"allergies" validate:"dive"`
+	ChronicConditions []ConditionInput  `json:"chronic_conditions" validate:"dive"`
+	Medications       []MedicationInput `json:"medications" validate:"dive"`
+	PregnancyStatus   string            `json:"pregnancy_status" validate:"required,oneof=unknown not_pregnant pregnant"`
+	PregnancyWeeks    *int              `json:"pregnancy_weeks" validate:"omitempty,min=1,max=45"`
+	SmokingStatus     string            `json:"smoking_status" validate:"required,oneof=unknown never former current"`
+}
+
+type ConditionInput struct {
+	Name      string `json:"name" validate:"required,max=255"`
+	ICD10Code string `json:"icd10_code" validate:"omitempty,max=10"`
+}
+
+type MedicationInput struct {
+	Name      string `json:"name" validate:"required,max=100"`
+	Dose      string `json:"dose" validate:"max=50"`
+	Frequency string `json:"frequency" validate:"max=50"`
+}
@@ -0,0 +1,30 @@
+package schemas
+
+type PrescriptionInput struct {
+	DoctorID        string                  `json:"doctor_id" validate:"required,uuid"`
+	Items           []PrescriptionItemInput `json:"items" validate:"required,min=1,dive"`
+	OverrideAllergy bool                    `json:"override_allergy"` // prescribe even if the patient is allergic
+}
+
+type PrescriptionItemInput struct {
+	DrugID       string `json:"drug_id" validate:"required,uuid"`
+	Dose         string `json:"dose" validate:"required,max=50"`
+	Route        string `json:"route" validate:"required,oneof=oral sublingual topical inhalation intravenous intramuscular subcutaneous rectal ophthalmic otic nasal vaginal"`
+	Frequency    string `json:"frequency" validate:"required,max=50"`
+	DurationDays int    `json:"duration_days" validate:"required,min=1,max=365"`
+	Notes        string `json:"notes"`
+}
+
+type AllergyInput struct {
+	Substance string `json:"substance" validate:"required,max=100"`
+	Reaction  string `json:"reaction" validate:"max=255"`
+	Severity  string `json:"severity" validate:"omitempty,oneof=mild moderate severe"`
+}
+
+// AllergyConflict tells which allergy of the patient a prescribed drug matches
+type AllergyConflict struct {
+	DrugID    string `json:"drug_id"`
+	DrugName  string `json:"drug_name"`
+	Substance string `json:"substance"`
+	Severity  string `json:"severity"`
+}
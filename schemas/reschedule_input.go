@@ -0,0 +1,6 @@
+package schemas
+
+type RescheduleInput struct {
+	DoctorID string `json:"doctor_id" validate:"omitempty,uuid"`
+	Date     string `json:"date" validate:"omitempty,datetime=2006-01-02"`
+}
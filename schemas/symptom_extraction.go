@@ -0,0 +1,23 @@
+package schemas
+
+import "github.com/BeeCodingAI/triana-api/models"
+
+// SymptomExtraction is the output of the symptom extraction LLM call
+type SymptomExtraction struct {
+	Symptoms []ExtractedSymptom `json:"symptoms"`
+}
+
+type ExtractedSymptom struct {
+	Symptom   string `json:"symptom"`
+	Onset     string `json:"onset"`
+	Duration  string `json:"duration"`
+	Severity  string `json:"severity"`
+	Location  string `json:"location"`
+	Modifiers string `json:"modifiers"`
+}
+
+// IntakeSummary is the concise view of the chat shown to the doctor
+type IntakeSummary struct {
+	Summary  string                  `json:"summary"`
+	Symptoms []models.SessionSymptom `json:"symptoms"`
+}
@@ -0,0 +1,8 @@
+package schemas
+
+import "time"
+
+type TimeSlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
@@ -0,0 +1,7 @@
+package schemas
+
+type TransferQueueInput struct {
+	DoctorID string `json:"doctor_id" validate:"required,uuid"`
+	Reason   string `json:"reason" validate:"required,max=500"`
+	Priority bool   `json:"priority"`
+}
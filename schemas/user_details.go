@@ -0,0 +1,40 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+)
+
+// UserDetails is the body of GET /user/:id, the patient with their latest session and the ones before it
+type UserDetails struct {
+	User            PatientDetails   `json:"user"`
+	CurrentSession  *SessionDetails  `json:"current_session"`
+	HistorySessions []SessionDetails `json:"history_sessions"` // newest first
+}
+
+type PatientDetails struct {
+	ID          uuid.UUID              `json:"id"`
+	Name        string                 `json:"name"`
+	Email       string                 `json:"email"`
+	Gender      string                 `json:"gender"`
+	Nationality string                 `json:"nationality"`
+	Age         string                 `json:"age"` // e.g. "21 years, 2 months, 3 days"
+	Profile     *models.PatientProfile `json:"profile"`
+}
+
+type SessionDetails struct {
+	SessionID        uuid.UUID                `json:"session_id"`
+	Queue            *models.Queue            `json:"queue"` // only set for the current session
+	Bodytemp         float32                  `json:"bodytemp"`
+	DoctorDiagnosis  string                   `json:"doctor_diagnosis"`
+	ConsultationNote *models.ConsultationNote `json:"consultation_note"`
+	CodedDiagnoses   []models.CodedDiagnosis  `json:"coded_diagnoses"`
+	Prescriptions    []models.Prescription    `json:"prescriptions"`
+	Heartrate        float32                  `json:"heartrate"`
+	Height           float32                  `json:"height"`
+	Prediagnosis     string                   `json:"prediagnosis"`
+	Weight           float32                  `json:"weight"`
+	CreatedAt        time.Time                `json:"created_at"`
+}
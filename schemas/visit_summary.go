@@ -0,0 +1,37 @@
+package schemas
+
+import (
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+)
+
+// VisitSummary is the take-home record of a session for the patient
+type VisitSummary struct {
+	SessionID       string                         `json:"session_id"`
+	VisitDate       time.Time                      `json:"visit_date"`
+	Patient         VisitPatient                   `json:"patient"`
+	Vitals          VisitVitals                    `json:"vitals"`
+	Symptoms        []models.SessionSymptom        `json:"symptoms"` // extracted from the chat
+	Doctor          *models.Doctor                 `json:"doctor"`
+	Prediagnosis    string                         `json:"prediagnosis"`
+	DoctorDiagnosis string                         `json:"doctor_diagnosis"`
+	Diagnoses       []models.ConsultationDiagnosis `json:"diagnoses"`
+	Prescriptions   []models.Prescription          `json:"prescriptions"`
+	Allergies       []models.PatientAllergy        `json:"allergies"`
+	FollowUp        string                         `json:"follow_up"` // plan section of the consultation note
+}
+
+type VisitPatient struct {
+	Name        string `json:"name"`
+	Age         string `json:"age"`
+	Gender      string `json:"gender"`
+	Nationality string `json:"nationality"`
+}
+
+type VisitVitals struct {
+	Weight    float32 `json:"weight"`
+	Height    float32 `json:"height"`
+	Heartrate float32 `json:"heartrate"`
+	Bodytemp  float32 `json:"bodytemp"`
+}
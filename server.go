@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+)
+
+// serve runs the HTTP server until ctx is done, then stops accepting connections and waits for the
+// in-flight requests until shutdownCtx expires
+func serve(ctx context.Context, shutdownCtx context.Context, cfg config.ServerConfig, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              cfg.Address,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if cfg.TLSCertFile != "" {
+			slog.Info("Listening", "address", cfg.Address, "tls", true)
+			serverErr <- server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			slog.Info("Listening", "address", cfg.Address, "tls", false)
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		// the server could not start, e.g. the address is in use
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, draining in-flight requests")
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// deadlineAfter returns a context that expires timeout after ctx is done, so that every step of the
+// shutdown shares one deadline that starts with the shutdown signal
+func deadlineAfter(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	deadlineCtx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-deadlineCtx.Done():
+			return
+		}
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel(context.DeadlineExceeded)
+		case <-deadlineCtx.Done():
+		}
+	}()
+	return deadlineCtx, func() { cancel(context.Canceled) }
+}
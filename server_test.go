@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+)
+
+// freeAddress returns a local address nobody listens on
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	address := freeAddress(t)
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond) // e.g. waiting for Gemini
+		w.Write([]byte("done"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	shutdownCtx, cancelShutdown := deadlineAfter(ctx, 5*time.Second)
+	defer cancelShutdown()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- serve(ctx, shutdownCtx, config.ServerConfig{Address: address}, handler)
+	}()
+
+	// wait for the server to listen
+	var response *http.Response
+	responseErr := make(chan error, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			var err error
+			if response, err = http.Get("http://" + address); err == nil {
+				responseErr <- nil
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		responseErr <- context.DeadlineExceeded
+	}()
+
+	select {
+	case <-started:
+	case err := <-responseErr:
+		t.Fatalf("request failed before reaching the handler: %v", err)
+	}
+	cancel()
+
+	if err := <-responseErr; err != nil {
+		t.Fatalf("in-flight request failed: %v", err)
+	}
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if string(body) != "done" {
+		t.Errorf("body = %q, want the full response", body)
+	}
+
+	if err := <-serveErr; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+	if _, err := http.Get("http://" + address); err == nil {
+		t.Error("server still accepts requests after the shutdown")
+	}
+}
+
+func TestServeReportsListenErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	err = serve(context.Background(), context.Background(), config.ServerConfig{Address: listener.Addr().String()}, http.NotFoundHandler())
+	if err == nil {
+		t.Error("serve started on an address in use")
+	}
+}
+
+func TestDeadlineAfterStartsWithTheSignal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	deadlineCtx, cancelDeadline := deadlineAfter(ctx, 50*time.Millisecond)
+	defer cancelDeadline()
+
+	select {
+	case <-deadlineCtx.Done():
+		t.Fatal("deadline expired before the signal")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-deadlineCtx.Done():
+		if cause := context.Cause(deadlineCtx); cause != context.DeadlineExceeded {
+			t.Errorf("cause = %v, want context.DeadlineExceeded", cause)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("deadline did not expire after the signal")
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RatePrediagnosis stores the doctor's rating of the AI prediagnosis, rating again replaces it
+func RatePrediagnosis(ctx context.Context, sessionID uuid.UUID, doctorID *uuid.UUID, rating int) error {
+	now := time.Now()
+	entry := models.PrediagnosisRating{
+		SessionID: sessionID,
+		DoctorID:  doctorID,
+		Rating:    rating,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := config.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"doctor_id", "rating", "updated_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to save prediagnosis rating: %w", err)
+	}
+
+	return nil
+}
+
+// ReconcileSession computes the evaluation of a diagnosed session, sessions without both diagnoses are skipped
+func ReconcileSession(ctx context.Context, sessionID uuid.UUID) error {
+	var session models.Session
+	err := config.DB.WithContext(ctx).Preload("CodedDiagnoses").Where("id = ?", sessionID).First(&session).Error
+	if err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+
+	if session.Prediagnosis == "" || session.DoctorDiagnosis == "" {
+		return nil
+	}
+
+	evaluation := models.PrediagnosisEvaluation{
+		SessionID:   session.ID,
+		Specialty:   "Unknown",
+		DiagnosedAt: session.UpdatedAt,
+		ComputedAt:  time.Now(),
+	}
+
+	// compare the codes, AI candidates are ranked and the doctor's primary code comes first
+	var aiCodes []string
+	for _, diagnosis := range session.CodedDiagnoses {
+		if diagnosis.Source == models.DiagnosisSourceAI {
+			aiCodes = append(aiCodes, diagnosis.Code)
+		}
+		if diagnosis.Source == models.DiagnosisSourceDoctor && diagnosis.Rank == 1 {
+			evaluation.DoctorCode = diagnosis.Code
+		}
+	}
+	if len(aiCodes) > 0 {
+		evaluation.AICode = aiCodes[0]
+	}
+
+	if evaluation.AICode != "" && evaluation.DoctorCode != "" {
+		evaluation.Coded = true
+		evaluation.ExactMatch = evaluation.AICode == evaluation.DoctorCode
+		evaluation.ChapterMatch = ICD10Chapter(evaluation.AICode) == ICD10Chapter(evaluation.DoctorCode)
+		for _, code := range aiCodes {
+			evaluation.CandidateMatch = evaluation.CandidateMatch || code == evaluation.DoctorCode
+		}
+	}
+
+	// the routing was correct when no doctor had to transfer the patient
+	if doctor := routedDoctor(ctx, session.ID); doctor != nil {
+		doctorID, _ := uuid.Parse(doctor.ID)
+		evaluation.RoutedDoctorID = &doctorID
+		evaluation.Specialty = doctor.Specialty
+		var transfers int64
+		config.DB.WithContext(ctx).Model(&models.QueueTransfer{}).Where("session_id = ?", session.ID).Count(&transfers)
+		evaluation.RoutingCorrect = transfers == 0
+	}
+
+	var rating models.PrediagnosisRating
+	if err := config.DB.WithContext(ctx).Where("session_id = ?", session.ID).First(&rating).Error; err == nil {
+		evaluation.Rating = &rating.Rating
+	}
+
+	err = config.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}},
+		UpdateAll: true,
+	}).Create(&evaluation).Error
+	if err != nil {
+		return fmt.Errorf("failed to save prediagnosis evaluation: %w", err)
+	}
+
+	return nil
+}
+
+// routedDoctor returns the doctor the LLM originally assigned the session to, from the first queue entry or appointment
+func routedDoctor(ctx context.Context, sessionID uuid.UUID) *models.Doctor {
+	var queue models.Queue
+	err := config.DB.WithContext(ctx).Preload("Doctor").Where("session_id = ?", sessionID).Order("created_at ASC").First(&queue).Error
+	if err == nil {
+		return &queue.Doctor
+	}
+
+	var appointment models.Appointment
+	err = config.DB.WithContext(ctx).Preload("Doctor").Where("session_id = ?", sessionID).Order("created_at ASC").First(&appointment).Error
+	if err == nil {
+		return &appointment.Doctor
+	}
+
+	return nil
+}
+
+// ReconcileAccuracy evaluates diagnosed sessions that have no evaluation yet or changed since the last one
+func ReconcileAccuracy(ctx context.Context) (int, error) {
+	var sessionIDs []uuid.UUID
+	err := config.DB.WithContext(ctx).Table("sessions").
+		Select("sessions.id").
+		Joins("LEFT JOIN prediagnosis_evaluations ON prediagnosis_evaluations.session_id = sessions.id").
+		Joins("LEFT JOIN prediagnosis_ratings ON prediagnosis_ratings.session_id = sessions.id").
+		Where("sessions.prediagnosis <> '' AND sessions.doctor_diagnosis <> ''").
+		Where("prediagnosis_evaluations.id IS NULL OR prediagnosis_evaluations.computed_at < sessions.updated_at OR prediagnosis_evaluations.computed_at < prediagnosis_ratings.updated_at").
+		Limit(500).
+		Pluck("sessions.id", &sessionIDs).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find sessions to reconcile: %w", err)
+	}
+
+	reconciled := 0
+	for _, sessionID := range sessionIDs {
+		if err := ReconcileSession(ctx, sessionID); err != nil {
+			slog.ErrorContext(ctx, "Error reconciling session", "session_id", sessionID, "error", err)
+			continue
+		}
+		reconciled++
+	}
+
+	return reconciled, nil
+}
+
+// RunAccuracyWorker reconciles prediagnosis evaluations periodically until the context is cancelled
+func RunAccuracyWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reconciled, err := ReconcileAccuracy(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error reconciling prediagnosis accuracy", "error", err)
+			} else if reconciled > 0 {
+				slog.InfoContext(ctx, "Reconciled prediagnosis accuracy", "count", reconciled)
+			}
+		}
+	}
+}
+
+const accuracyStatsSelect = `COUNT(*) AS sessions,
+	COUNT(*) FILTER (WHERE coded) AS coded_sessions,
+	AVG(CASE WHEN exact_match THEN 1.0 ELSE 0.0 END) FILTER (WHERE coded) AS exact_match_rate,
+	AVG(CASE WHEN candidate_match THEN 1.0 ELSE 0.0 END) FILTER (WHERE coded) AS candidate_match_rate,
+	AVG(CASE WHEN chapter_match THEN 1.0 ELSE 0.0 END) FILTER (WHERE coded) AS chapter_match_rate,
+	COUNT(routed_doctor_id) AS routed_sessions,
+	AVG(CASE WHEN routing_correct THEN 1.0 ELSE 0.0 END) FILTER (WHERE routed_doctor_id IS NOT NULL) AS routing_correct_rate,
+	COUNT(rating) AS rated_sessions,
+	AVG(rating) AS average_rating`
+
+var accuracyPeriods = map[string]bool{"day": true, "week": true, "month": true}
+
+// GetAccuracyReport aggregates the evaluations of sessions diagnosed in [from, to) overall, by specialty and by period
+func GetAccuracyReport(ctx context.Context, from time.Time, to time.Time, period string) (*schemas.AccuracyReport, error) {
+	if !accuracyPeriods[period] {
+		return nil, Validation("invalid_period", fmt.Sprintf("invalid period %q, use day, week or month", period))
+	}
+
+	report := schemas.AccuracyReport{
+		From:        from,
+		To:          to,
+		Period:      period,
+		BySpecialty: []schemas.AccuracyGroup{},
+		ByPeriod:    []schemas.AccuracyGroup{},
+	}
+
+	// the base query is shared by the three aggregations
+	base := config.DB.WithContext(ctx).Model(&models.PrediagnosisEvaluation{}).
+		Where("diagnosed_at >= ? AND diagnosed_at < ?", from, to).
+		Session(&gorm.Session{})
+
+	err := base.Select(accuracyStatsSelect).Scan(&report.Overall).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate accuracy: %w", err)
+	}
+
+	err = base.
+		Select("specialty AS key, " + accuracyStatsSelect).
+		Group("specialty").Order("specialty ASC").
+		Scan(&report.BySpecialty).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate accuracy by specialty: %w", err)
+	}
+
+	// the period is whitelisted above, so it is safe to put in the query
+	periodKey := fmt.Sprintf("to_char(date_trunc('%s', diagnosed_at), 'YYYY-MM-DD')", period)
+	err = base.
+		Select(periodKey + " AS key, " + accuracyStatsSelect).
+		Group("1").Order("1 ASC").
+		Scan(&report.ByPeriod).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate accuracy by period: %w", err)
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+)
+
+func GetPatientAllergies(userID uuid.UUID) []models.PatientAllergy {
+	var allergies []models.PatientAllergy
+	config.DB.Where("user_id = ?", userID).Order("substance ASC").Find(&allergies)
+	return allergies
+}
+
+func AddPatientAllergy(userID uuid.UUID, input schemas.AllergyInput) (*models.PatientAllergy, error) {
+	allergy := models.PatientAllergy{
+		UserID:    userID,
+		Substance: strings.TrimSpace(input.Substance),
+		Reaction:  input.Reaction,
+		Severity:  input.Severity,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := config.DB.Create(&allergy).Error; err != nil {
+		return nil, fmt.Errorf("failed to save allergy: %w", err)
+	}
+
+	return &allergy, nil
+}
+
+// DeletePatientAllergy removes an allergy of the user, returns false if the user has no such allergy
+func DeletePatientAllergy(userID uuid.UUID, allergyID string) (bool, error) {
+	result := config.DB.Where("id = ? AND user_id = ?", allergyID, userID).Delete(&models.PatientAllergy{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete allergy: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// allergyMatches is a simple check whether a drug contains or belongs to the class of an allergen,
+// e.g. an allergy to "penicillin" matches amoxicillin
+func allergyMatches(allergy models.PatientAllergy, drug models.Drug) bool {
+	substance := strings.ToLower(strings.TrimSpace(allergy.Substance))
+	if substance == "" {
+		return false
+	}
+
+	name := strings.ToLower(drug.Name)
+	if strings.Contains(name, substance) || strings.Contains(strings.ToLower(drug.DrugClass), substance) {
+		return true
+	}
+
+	// every substance contains an empty generic name, drugs without one are only matched by name and class
+	generic := strings.ToLower(strings.TrimSpace(drug.GenericName))
+	return generic != "" && (strings.Contains(generic, substance) || strings.Contains(substance, generic))
+}
+
+// checkAllergies returns the allergies of the patient matched by the drugs
+func checkAllergies(allergies []models.PatientAllergy, drugs []models.Drug) []schemas.AllergyConflict {
+	var conflicts []schemas.AllergyConflict
+	for _, drug := range drugs {
+		for _, allergy := range allergies {
+			if allergyMatches(allergy, drug) {
+				conflicts = append(conflicts, schemas.AllergyConflict{
+					DrugID:    drug.ID.String(),
+					DrugName:  drug.Name,
+					Substance: allergy.Substance,
+					Severity:  allergy.Severity,
+				})
+			}
+		}
+	}
+	return conflicts
+}
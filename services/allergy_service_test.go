@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/models"
+)
+
+func TestAllergyMatches(t *testing.T) {
+	amoxicillin := models.Drug{Name: "Amoxicillin 500 mg", GenericName: "amoxicillin", DrugClass: "penicillin"}
+	tests := []struct {
+		name      string
+		substance string
+		drug      models.Drug
+		want      bool
+	}{
+		{"brand name", "amoxicillin", amoxicillin, true},
+		{"drug class", "Penicillin", amoxicillin, true},
+		{"generic name in substance", "amoxicillin trihydrate", amoxicillin, true},
+		{"unrelated", "ibuprofen", amoxicillin, false},
+		{"empty substance", " ", amoxicillin, false},
+		{"drug without generic name", "peanuts", models.Drug{Name: "Vitamin C 500 mg", DrugClass: "vitamin"}, false},
+		{"drug without generic name by name", "vitamin c", models.Drug{Name: "Vitamin C 500 mg"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := allergyMatches(models.PatientAllergy{Substance: tt.substance}, tt.drug); got != tt.want {
+			t.Errorf("%s: allergyMatches(%q, %q) = %v, want %v", tt.name, tt.substance, tt.drug.Name, got, tt.want)
+		}
+	}
+}
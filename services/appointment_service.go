@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSlotUnavailable = Conflict("slot_unavailable", "time slot is not available")
+	ErrAlreadyBooked   = Conflict("already_booked", "session already has a booked appointment")
+)
+
+func GetDoctorSchedules(ctx context.Context, doctorID uuid.UUID) []models.DoctorSchedule {
+	var schedules []models.DoctorSchedule
+	err := config.DB.WithContext(ctx).Where("doctor_id = ?", doctorID).Order("weekday ASC, start_time ASC").Find(&schedules).Error
+	if err != nil {
+		return nil
+	}
+	return schedules
+}
+
+// UpdateDoctorSchedules replaces the weekly schedule of a doctor
+func UpdateDoctorSchedules(ctx context.Context, doctorID uuid.UUID, input schemas.DoctorScheduleInput) ([]models.DoctorSchedule, error) {
+	var schedules []models.DoctorSchedule
+	for _, item := range input.Schedules {
+		if item.StartTime >= item.EndTime {
+			return nil, Validation("invalid_schedule", fmt.Sprintf("start time %s must be before end time %s", item.StartTime, item.EndTime))
+		}
+
+		schedules = append(schedules, models.DoctorSchedule{
+			DoctorID:    doctorID,
+			Weekday:     item.Weekday,
+			StartTime:   item.StartTime,
+			EndTime:     item.EndTime,
+			SlotMinutes: item.SlotMinutes,
+		})
+	}
+
+	err := config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("doctor_id = ?", doctorID).Delete(&models.DoctorSchedule{}).Error; err != nil {
+			return err
+		}
+		if len(schedules) == 0 {
+			return nil
+		}
+		return tx.Create(&schedules).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+// GetAvailableSlots lists the free slots of a doctor on the given date, past slots are left out
+func GetAvailableSlots(ctx context.Context, doctorID uuid.UUID, date time.Time) ([]schemas.TimeSlot, error) {
+	dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var schedules []models.DoctorSchedule
+	err := config.DB.WithContext(ctx).Where("doctor_id = ? AND weekday = ?", doctorID, int(dayStart.Weekday())).Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schedules: %w", err)
+	}
+
+	var booked []models.Appointment
+	err = config.DB.WithContext(ctx).
+		Where("doctor_id = ?", doctorID).
+		Where("status <> ?", models.AppointmentStatusCancelled).
+		Where("starts_at >= ? AND starts_at < ?", dayStart, dayEnd).
+		Find(&booked).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch appointments: %w", err)
+	}
+
+	bookedSlots := map[string]bool{}
+	for _, appointment := range booked {
+		bookedSlots[slotKey(appointment.StartsAt)] = true
+	}
+
+	now := time.Now()
+	slots := []schemas.TimeSlot{}
+	for _, schedule := range schedules {
+		for _, slot := range scheduleSlots(schedule, dayStart) {
+			if slot.StartsAt.After(now) && !bookedSlots[slotKey(slot.StartsAt)] {
+				slots = append(slots, slot)
+			}
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].StartsAt.Before(slots[j].StartsAt)
+	})
+
+	return slots, nil
+}
+
+// scheduleSlots splits a schedule window on the given day into slots
+func scheduleSlots(schedule models.DoctorSchedule, day time.Time) []schemas.TimeSlot {
+	start, err := time.Parse("15:04", schedule.StartTime)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse("15:04", schedule.EndTime)
+	if err != nil || schedule.SlotMinutes <= 0 {
+		return nil
+	}
+
+	slotStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, time.Local)
+	windowEnd := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, time.Local)
+	length := time.Duration(schedule.SlotMinutes) * time.Minute
+
+	var slots []schemas.TimeSlot
+	for !slotStart.Add(length).After(windowEnd) {
+		slots = append(slots, schemas.TimeSlot{StartsAt: slotStart, EndsAt: slotStart.Add(length)})
+		slotStart = slotStart.Add(length)
+	}
+
+	return slots
+}
+
+// slotKey compares slots by wall clock, timestamps read back from the database lose their location
+func slotKey(t time.Time) string {
+	return t.Format("2006-01-02T15:04")
+}
+
+func BookAppointment(ctx context.Context, sessionID string, input schemas.BookAppointmentInput) (*models.Appointment, error) {
+	doctorID, err := uuid.Parse(input.DoctorID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid doctor ID: %w", err)
+	}
+
+	startsAt := input.StartsAt.In(time.Local)
+	slots, err := GetAvailableSlots(ctx, doctorID, startsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slot := range slots {
+		if slotKey(slot.StartsAt) == slotKey(startsAt) {
+			return createAppointment(ctx, sessionID, doctorID, slot)
+		}
+	}
+
+	return nil, ErrSlotUnavailable
+}
+
+// BookFirstAvailableSlot books the earliest free slot of the doctor on the given date
+func BookFirstAvailableSlot(ctx context.Context, sessionID string, doctorID string, date time.Time) (*models.Appointment, error) {
+	doctorUUID, err := uuid.Parse(doctorID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid doctor ID: %w", err)
+	}
+
+	slots, err := GetAvailableSlots(ctx, doctorUUID, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, ErrSlotUnavailable
+	}
+
+	return createAppointment(ctx, sessionID, doctorUUID, slots[0])
+}
+
+func createAppointment(ctx context.Context, sessionID string, doctorID uuid.UUID, slot schemas.TimeSlot) (*models.Appointment, error) {
+	sessionUUID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session ID: %w", err)
+	}
+
+	now := time.Now()
+	appointment := models.Appointment{
+		DoctorID:  doctorID,
+		SessionID: sessionUUID,
+		StartsAt:  slot.StartsAt,
+		EndsAt:    slot.EndsAt,
+		Status:    models.AppointmentStatusBooked,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// one booked appointment per session, the session row is locked so concurrent chat turns book one after another
+		var session models.Session
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", sessionUUID).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch session: %w", err)
+		}
+
+		var count int64
+		err = tx.Model(&models.Appointment{}).
+			Where("session_id = ? AND status = ?", sessionUUID, models.AppointmentStatusBooked).
+			Count(&count).Error
+		if err != nil {
+			return fmt.Errorf("failed to count booked appointments: %w", err)
+		}
+		if count > 0 {
+			return ErrAlreadyBooked
+		}
+
+		// the unique index on doctor and slot rejects concurrent bookings of the same slot
+		err = tx.Create(&appointment).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrSlotUnavailable
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create appointment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = config.DB.WithContext(ctx).Preload("Doctor").Where("id = ?", appointment.ID).First(&appointment).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch appointment: %w", err)
+	}
+
+	return &appointment, nil
+}
+
+func GetAppointmentByID(ctx context.Context, appointmentID uuid.UUID) *models.Appointment {
+	var appointment models.Appointment
+	err := config.DB.WithContext(ctx).Preload("Doctor").Where("id = ?", appointmentID).First(&appointment).Error
+	if err != nil {
+		return nil
+	}
+	return &appointment
+}
+
+// CheckInAppointment turns a booked appointment into a queue entry when the patient arrives
+func (s *QueueService) CheckInAppointment(ctx context.Context, appointmentID uuid.UUID) (*models.Queue, error) {
+	appointment := GetAppointmentByID(ctx, appointmentID)
+	if appointment == nil {
+		return nil, ErrAppointmentNotFound
+	}
+
+	if appointment.Status != models.AppointmentStatusBooked {
+		return nil, Conflict("appointment_not_booked", fmt.Sprintf("appointment is %s", appointment.Status))
+	}
+
+	if appointment.StartsAt.Format("2006-01-02") != time.Now().Format("2006-01-02") {
+		return nil, Conflict("appointment_not_today", fmt.Sprintf("appointment is scheduled on %s", appointment.StartsAt.Format("2006-01-02")))
+	}
+
+	queue, err := s.GenerateQueue(ctx, appointment.SessionID.String(), appointment.DoctorID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// the patient is at the clinic already
+	if err := markQueueArrived(ctx, s.queues, queue); err != nil {
+		return nil, err
+	}
+
+	appointment.Status = models.AppointmentStatusCheckedIn
+	appointment.QueueID = &queue.ID
+	appointment.UpdatedAt = time.Now()
+	if err := config.DB.WithContext(ctx).Save(appointment).Error; err != nil {
+		return nil, fmt.Errorf("failed to update appointment: %w", err)
+	}
+
+	return queue, nil
+}
+
+// CancelAppointment cancels a booked appointment, which frees the slot, and lets the doctor know
+func CancelAppointment(ctx context.Context, appointmentID uuid.UUID) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := config.DB.WithContext(ctx).Preload("Doctor").Preload("Session.User").Where("id = ?", appointmentID).First(&appointment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAppointmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch appointment: %w", err)
+	}
+
+	if appointment.Status != models.AppointmentStatusBooked {
+		return nil, Conflict("appointment_not_booked", fmt.Sprintf("appointment is %s", appointment.Status))
+	}
+
+	appointment.Status = models.AppointmentStatusCancelled
+	appointment.UpdatedAt = time.Now()
+	err = config.DB.WithContext(ctx).Model(&models.Appointment{}).Where("id = ?", appointment.ID).
+		Updates(map[string]interface{}{"status": appointment.Status, "updated_at": appointment.UpdatedAt}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update appointment: %w", err)
+	}
+
+	NotifyDoctor(ctx, appointment.Doctor, "Appointment Cancelled", fmt.Sprintf(
+		"%s has cancelled their appointment on %s.",
+		appointment.Session.User.Name, appointment.StartsAt.Format("2006-01-02 15:04"),
+	))
+
+	return &appointment, nil
+}
+
+func SendAppointmentEmail(ctx context.Context, to string, appointment models.Appointment, token string, attachments []schemas.EmailAttachment) (map[string]interface{}, error) {
+	email := schemas.Email{
+		To:          to,
+		Subject:     "Appointment Confirmation",
+		Body:        "Your appointment has been booked",
+		From:        "triana@ai.com",
+		HTML:        injectAppointmentIntoHTML(appointment),
+		Attachments: attachments,
+	}
+
+	return sendEmail(ctx, "appointment", email, token)
+}
+
+func injectAppointmentIntoHTML(appointment models.Appointment) string {
+	htmlString := readEmailTemplate("emails/appointment_mail.html")
+
+	htmlString = strings.ReplaceAll(htmlString, "{{appointment_date}}", appointment.StartsAt.Format("Monday, 02 January 2006"))
+	htmlString = strings.ReplaceAll(htmlString, "{{appointment_time}}", appointment.StartsAt.Format("15:04"))
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_name}}", appointment.Doctor.Name)
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_specialty}}", appointment.Doctor.Specialty)
+	htmlString = strings.ReplaceAll(htmlString, "{{room_number}}", appointment.Doctor.Roomno)
+	htmlString = strings.ReplaceAll(htmlString, "{{cancel_url}}", signedLink("appointment", "cancel", appointment.ID, appointmentLinkExpiry(appointment)))
+
+	return htmlString
+}
+
+// releaseAppointment cancels an appointment booked for a change that failed afterwards, the doctor was not told about it yet
+func releaseAppointment(ctx context.Context, appointment *models.Appointment) {
+	// the change may have failed because the request was cancelled, the slot is freed anyway
+	err := config.DB.WithContext(context.WithoutCancel(ctx)).Model(&models.Appointment{}).Where("id = ?", appointment.ID).
+		Updates(map[string]interface{}{"status": models.AppointmentStatusCancelled, "updated_at": time.Now()}).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Error releasing appointment", "appointment_id", appointment.ID, "error", err)
+	}
+}
+
+// ParseFutureDate parses a YYYY-MM-DD date and reports whether it is after today
+func ParseFutureDate(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	return date, date.After(today)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+)
+
+func TestScheduleSlots(t *testing.T) {
+	day := time.Date(2025, 5, 19, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		schedule models.DoctorSchedule
+		want     []string
+	}{
+		{"whole slots", models.DoctorSchedule{StartTime: "09:00", EndTime: "10:00", SlotMinutes: 20}, []string{"09:00", "09:20", "09:40"}},
+		{"partial slot at the end is left out", models.DoctorSchedule{StartTime: "09:00", EndTime: "09:50", SlotMinutes: 20}, []string{"09:00", "09:20"}},
+		{"window shorter than a slot", models.DoctorSchedule{StartTime: "09:00", EndTime: "09:10", SlotMinutes: 15}, nil},
+		{"invalid slot length", models.DoctorSchedule{StartTime: "09:00", EndTime: "10:00", SlotMinutes: 0}, nil},
+		{"invalid time", models.DoctorSchedule{StartTime: "9am", EndTime: "10:00", SlotMinutes: 15}, nil},
+	}
+
+	for _, tt := range tests {
+		slots := scheduleSlots(tt.schedule, day)
+		if len(slots) != len(tt.want) {
+			t.Errorf("%s: got %d slots, want %d", tt.name, len(slots), len(tt.want))
+			continue
+		}
+		for i, slot := range slots {
+			if got := slot.StartsAt.Format("15:04"); got != tt.want[i] || slot.StartsAt.Day() != day.Day() {
+				t.Errorf("%s: slot %d starts at %s, want %s", tt.name, i, slot.StartsAt, tt.want[i])
+			}
+			if slot.EndsAt.Sub(slot.StartsAt) != time.Duration(tt.schedule.SlotMinutes)*time.Minute {
+				t.Errorf("%s: slot %d lasts %s", tt.name, i, slot.EndsAt.Sub(slot.StartsAt))
+			}
+		}
+	}
+}
+
+func TestParseFutureDate(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	tests := []struct {
+		value  string
+		future bool
+	}{
+		{tomorrow, true},
+		{time.Now().Format("2006-01-02"), false},
+		{"2000-01-01", false},
+		{"", false},
+		{"next monday", false},
+	}
+
+	for _, tt := range tests {
+		if _, future := ParseFutureDate(tt.value); future != tt.future {
+			t.Errorf("ParseFutureDate(%q) = %v, want %v", tt.value, future, tt.future)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// background tracks the work started after a response was sent, e.g. the handoff summary,
+// so the server can wait for it before shutting down
+var background sync.WaitGroup
+
+// runInBackground runs job in a goroutine tracked for the shutdown
+func runInBackground(job func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		job()
+	}()
+}
+
+// WaitForBackground waits for the background work to finish, or until ctx is done
+func WaitForBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
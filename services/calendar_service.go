@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+)
+
+// average time a doctor spends with one patient, used to estimate appointment times
+const consultationDuration = 15 * time.Minute
+
+type calendarEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Location    string
+	Description string
+	URL         string
+}
+
+func (s *QueueService) GenerateQueueCalendar(ctx context.Context, queueID uuid.UUID) (string, error) {
+	queue, err := s.queues.FindByID(ctx, queueID)
+	if err != nil {
+		return "", fmt.Errorf("queue not found: %w", err)
+	}
+
+	start := s.estimateQueueTime(ctx, queue)
+	cancelURL := signedLink("queue", "cancel", queue.ID, queueLinkExpiry(*queue))
+
+	event := calendarEvent{
+		UID:      fmt.Sprintf("%s@triana", queue.ID),
+		Start:    start,
+		End:      start.Add(consultationDuration),
+		Summary:  fmt.Sprintf("Appointment with %s", queue.Doctor.Name),
+		Location: fmt.Sprintf("Room %s", queue.Doctor.Roomno),
+		Description: fmt.Sprintf(
+			"Doctor: %s (%s)\nRoom: %s\nQueue number: %d\nThe time is an estimate based on the current queue.\nCancel appointment: %s",
+			queue.Doctor.Name, queue.Doctor.Specialty, queue.Doctor.Roomno, queue.Number, cancelURL,
+		),
+		URL: cancelURL,
+	}
+
+	return buildICS(event), nil
+}
+
+func GenerateAppointmentCalendar(ctx context.Context, appointmentID uuid.UUID) (string, error) {
+	appointment := GetAppointmentByID(ctx, appointmentID)
+	if appointment == nil {
+		return "", ErrAppointmentNotFound
+	}
+
+	cancelURL := signedLink("appointment", "cancel", appointment.ID, appointmentLinkExpiry(*appointment))
+
+	event := calendarEvent{
+		UID:      fmt.Sprintf("%s@triana", appointment.ID),
+		Start:    storedLocalTime(appointment.StartsAt),
+		End:      storedLocalTime(appointment.EndsAt),
+		Summary:  fmt.Sprintf("Appointment with %s", appointment.Doctor.Name),
+		Location: fmt.Sprintf("Room %s", appointment.Doctor.Roomno),
+		Description: fmt.Sprintf(
+			"Doctor: %s (%s)\nRoom: %s\nPlease check in at the clinic on arrival to get your queue number.\nCancel appointment: %s",
+			appointment.Doctor.Name, appointment.Doctor.Specialty, appointment.Doctor.Roomno, cancelURL,
+		),
+		URL: cancelURL,
+	}
+
+	return buildICS(event), nil
+}
+
+// estimateQueueTime guesses when the patient will be called from the number of patients ahead of them
+func (s *QueueService) estimateQueueTime(ctx context.Context, queue *models.Queue) time.Time {
+	now := time.Now()
+
+	// queue entries from previous days are not estimated anymore
+	if queue.CreatedAt.Before(now.Truncate(24 * time.Hour)) {
+		return queue.CreatedAt
+	}
+
+	currentQueue, err := s.GetCurrentQueue(ctx, queue.DoctorID)
+	if err != nil || currentQueue.Number >= queue.Number {
+		return now
+	}
+
+	return now.Add(time.Duration(queue.Number-currentQueue.Number) * consultationDuration)
+}
+
+func buildICS(event calendarEvent) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Triana//Triana API//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"BEGIN:VEVENT",
+		"UID:" + event.UID,
+		"DTSTAMP:" + formatICSTime(time.Now()),
+		"DTSTART:" + formatICSTime(event.Start),
+		"DTEND:" + formatICSTime(event.End),
+		"SUMMARY:" + escapeICSText(event.Summary),
+		"LOCATION:" + escapeICSText(event.Location),
+		"DESCRIPTION:" + escapeICSText(event.Description),
+		"URL:" + event.URL,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+
+	var builder strings.Builder
+	for _, line := range lines {
+		builder.WriteString(foldICSLine(line))
+		builder.WriteString("\r\n")
+	}
+
+	return builder.String()
+}
+
+func formatICSTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICSText escapes the characters that have a meaning in iCalendar TEXT values (RFC 5545 3.3.11)
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// foldICSLine splits lines longer than 75 octets, continuation lines start with a space (RFC 5545 3.1)
+func foldICSLine(line string) string {
+	const limit = 75
+
+	var builder strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > limit {
+			builder.WriteString("\r\n ")
+			length = 1
+		}
+		builder.WriteRune(r)
+		length += size
+	}
+
+	return builder.String()
+}
+
+func NewCalendarAttachment(ics string) schemas.EmailAttachment {
+	return schemas.EmailAttachment{
+		Filename:    "appointment.ics",
+		Content:     base64.StdEncoding.EncodeToString([]byte(ics)),
+		ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+	}
+}
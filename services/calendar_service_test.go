@@ -0,0 +1,98 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeICSText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Room 12", "Room 12"},
+		{"Dr. Budi, Sp.PD; Internist", `Dr. Budi\, Sp.PD\; Internist`},
+		{`C:\rooms`, `C:\\rooms`},
+		{"line one\nline two\r\nline three", `line one\nline two\nline three`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICSText(tt.text); got != tt.want {
+			t.Errorf("escapeICSText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Appointment"},
+		{"exactly 75 octets", "DESCRIPTION:" + strings.Repeat("a", 63)},
+		{"long ASCII", "DESCRIPTION:" + strings.Repeat("a", 200)},
+		{"multi-byte runes", "DESCRIPTION:" + strings.Repeat("é", 100)},
+	}
+
+	for _, tt := range tests {
+		folded := foldICSLine(tt.line)
+		lines := strings.Split(folded, "\r\n")
+		for i, line := range lines {
+			if len(line) > 75 {
+				t.Errorf("%s: line %d has %d octets, want at most 75", tt.name, i, len(line))
+			}
+			if i > 0 && !strings.HasPrefix(line, " ") {
+				t.Errorf("%s: continuation line %d does not start with a space", tt.name, i)
+			}
+		}
+		if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != tt.line {
+			t.Errorf("%s: unfolding gives %q, want the original line", tt.name, unfolded)
+		}
+		if len(tt.line) <= 75 && len(lines) != 1 {
+			t.Errorf("%s: folded a line of %d octets", tt.name, len(tt.line))
+		}
+	}
+}
+
+func TestBuildICS(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	tests := []struct {
+		name  string
+		start time.Time
+		want  []string
+	}{
+		{"UTC", time.Date(2025, 5, 16, 9, 30, 0, 0, time.UTC), []string{"DTSTART:20250516T093000Z\r\n", "DTEND:20250516T094500Z\r\n"}},
+		{"UTC+7", time.Date(2025, 5, 16, 9, 30, 0, 0, jakarta), []string{"DTSTART:20250516T023000Z\r\n", "DTEND:20250516T024500Z\r\n"}},
+	}
+
+	for _, tt := range tests {
+		ics := buildICS(calendarEvent{
+			UID:         "queue-1@triana",
+			Start:       tt.start,
+			End:         tt.start.Add(consultationDuration),
+			Summary:     "Appointment with Dr. Budi",
+			Location:    "Room 12",
+			Description: "Queue number: 3",
+			URL:         "https://app.example.com/queue/1/cancel?token=abc",
+		})
+
+		for _, want := range append(tt.want, "BEGIN:VCALENDAR\r\n", "END:VCALENDAR\r\n") {
+			if !strings.Contains(ics, want) {
+				t.Errorf("%s: calendar does not contain %q:\n%s", tt.name, want, ics)
+			}
+		}
+	}
+}
+
+// an appointment at 09:30 in Jakarta is read back from the timestamp column as 09:30 UTC
+func TestStoredLocalTimeInICS(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("WIB", 7*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	stored := time.Date(2025, 5, 16, 9, 30, 0, 0, time.UTC)
+	if got := formatICSTime(storedLocalTime(stored)); got != "20250516T023000Z" {
+		t.Errorf("DTSTART = %s, want 20250516T023000Z", got)
+	}
+}
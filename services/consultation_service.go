@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrConsultationNoteNotFound = NotFound("consultation_note_not_found", "consultation note not found")
+
+// SaveConsultationNote creates or edits the consultation note of a session, every save is kept as a new revision
+func (s *SessionService) SaveConsultationNote(ctx context.Context, sessionID string, input schemas.ConsultationNoteInput) (*models.ConsultationNote, error) {
+	doctorID, err := uuid.Parse(input.DoctorID)
+	if err != nil {
+		return nil, Validation("invalid_doctor_id", "invalid doctor ID")
+	}
+	doctor := s.doctors.GetDoctorByID(ctx, input.DoctorID)
+	if doctor == nil {
+		return nil, ErrDoctorNotFound
+	}
+
+	var note models.ConsultationNote
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the session row is locked so concurrent saves, also the first one that creates the note, run one after another
+		var session models.Session
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", sessionID).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch session: %w", err)
+		}
+
+		now := time.Now()
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("session_id = ?", session.ID).First(&note).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			note = models.ConsultationNote{SessionID: session.ID, Version: 0, CreatedAt: now}
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch consultation note: %w", err)
+		}
+
+		note.DoctorID = doctorID
+		note.Subjective = input.Subjective
+		note.Objective = input.Objective
+		note.Assessment = input.Assessment
+		note.Plan = input.Plan
+		note.Notes = input.Notes
+		note.Version++
+		note.UpdatedAt = now
+		note.Diagnoses = nil
+
+		if err := tx.Omit("Diagnoses", "Doctor").Save(&note).Error; err != nil {
+			return fmt.Errorf("failed to save consultation note: %w", err)
+		}
+
+		// the diagnoses are replaced on every edit
+		if err := tx.Where("note_id = ?", note.ID).Delete(&models.ConsultationDiagnosis{}).Error; err != nil {
+			return fmt.Errorf("failed to update diagnoses: %w", err)
+		}
+		note.Diagnoses = buildDiagnoses(note.ID, input.Diagnoses)
+		if err := tx.Create(&note.Diagnoses).Error; err != nil {
+			return fmt.Errorf("failed to save diagnoses: %w", err)
+		}
+
+		// the coded diagnoses are used to compare the AI prediagnosis with the doctor's diagnosis
+		if err := saveDoctorDiagnosisCodes(tx, session.ID, diagnosisCodes(note.Diagnoses)); err != nil {
+			return err
+		}
+
+		// chronic diagnoses are kept on the patient's profile for later visits
+		if err := recordChronicConditions(tx, session.UserID, session.ID, chronicConditions(note.Diagnoses)); err != nil {
+			return err
+		}
+
+		note.Doctor = *doctor
+		content, err := json.Marshal(note)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot consultation note: %w", err)
+		}
+		revision := models.ConsultationNoteRevision{
+			NoteID:    note.ID,
+			Version:   note.Version,
+			DoctorID:  doctorID,
+			Content:   content,
+			CreatedAt: now,
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return fmt.Errorf("failed to save revision: %w", err)
+		}
+
+		// keep the short diagnosis on the session for the queue and older clients
+		session.DoctorDiagnosis = truncate(primaryDiagnosis(note.Diagnoses), 100)
+		session.UpdatedAt = now
+		return tx.Model(&models.Session{}).Where("id = ?", session.ID).
+			Updates(map[string]interface{}{"doctor_diagnosis": session.DoctorDiagnosis, "updated_at": session.UpdatedAt}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// buildDiagnoses converts the input, the first diagnosis is primary when none is marked
+func buildDiagnoses(noteID uuid.UUID, inputs []schemas.DiagnosisInput) []models.ConsultationDiagnosis {
+	hasPrimary := false
+	for _, input := range inputs {
+		hasPrimary = hasPrimary || input.IsPrimary
+	}
+
+	var diagnoses []models.ConsultationDiagnosis
+	for i, input := range inputs {
+		diagnoses = append(diagnoses, models.ConsultationDiagnosis{
+			NoteID:      noteID,
+			Description: input.Description,
+			ICD10Code:   NormalizeICD10Code(input.ICD10Code),
+			IsPrimary:   input.IsPrimary || (!hasPrimary && i == 0),
+			IsChronic:   input.IsChronic || IsChronicICD10Code(input.ICD10Code),
+		})
+	}
+
+	return diagnoses
+}
+
+func chronicConditions(diagnoses []models.ConsultationDiagnosis) []models.PatientCondition {
+	var conditions []models.PatientCondition
+	for _, diagnosis := range diagnoses {
+		if diagnosis.IsChronic {
+			conditions = append(conditions, models.PatientCondition{Name: diagnosis.Description, ICD10Code: diagnosis.ICD10Code})
+		}
+	}
+	return conditions
+}
+
+// diagnosisCodes lists the ICD-10 codes of the diagnoses, the primary diagnosis first
+func diagnosisCodes(diagnoses []models.ConsultationDiagnosis) []string {
+	var codes []string
+	for _, diagnosis := range diagnoses {
+		if diagnosis.ICD10Code == "" {
+			continue
+		}
+		if diagnosis.IsPrimary {
+			codes = append([]string{diagnosis.ICD10Code}, codes...)
+		} else {
+			codes = append(codes, diagnosis.ICD10Code)
+		}
+	}
+	return codes
+}
+
+func primaryDiagnosis(diagnoses []models.ConsultationDiagnosis) string {
+	for _, diagnosis := range diagnoses {
+		if diagnosis.IsPrimary {
+			return diagnosis.Description
+		}
+	}
+	return ""
+}
+
+// truncate shortens text to at most max characters
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max])
+}
+
+func GetConsultationNote(ctx context.Context, sessionID string) (*models.ConsultationNote, error) {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, ErrConsultationNoteNotFound
+	}
+
+	var note models.ConsultationNote
+	err = config.DB.WithContext(ctx).Preload("Doctor").Preload("Diagnoses").Where("session_id = ?", id).First(&note).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrConsultationNoteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch consultation note: %w", err)
+	}
+	return &note, nil
+}
+
+func GetConsultationNoteHistory(ctx context.Context, sessionID string) ([]models.ConsultationNoteRevision, error) {
+	note, err := GetConsultationNote(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []models.ConsultationNoteRevision
+	err = config.DB.WithContext(ctx).Where("note_id = ?", note.ID).Order("version DESC").Find(&revisions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revisions: %w", err)
+	}
+
+	return revisions, nil
+}
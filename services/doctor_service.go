@@ -1,29 +1,53 @@
 package services
 
 import (
-	"github.com/BeeCodingAI/triana-api/config"
+	"context"
+	"log/slog"
+
 	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
 	"github.com/google/uuid"
 )
 
-func GetAllDoctors() []models.Doctor {
-	var doctors []models.Doctor
-	err := config.DB.Find(&doctors).Error
+type DoctorService struct {
+	doctors repositories.DoctorRepository
+}
+
+func NewDoctorService(doctors repositories.DoctorRepository) *DoctorService {
+	return &DoctorService{doctors: doctors}
+}
+
+func (s *DoctorService) GetAllDoctors(ctx context.Context) []models.Doctor {
+	doctors, err := s.doctors.FindAll(ctx)
 	if err != nil {
 		return nil
 	}
 	return doctors
 }
 
-func GetDoctorByID(doctorID string) *models.Doctor {
-	var doctor models.Doctor
+func (s *DoctorService) GetDoctorByID(ctx context.Context, doctorID string) *models.Doctor {
 	id, err := uuid.Parse(doctorID)
 	if err != nil {
 		return nil
 	}
-	err = config.DB.First(&doctor, "id = ?", id).Error
+	doctor, err := s.doctors.FindByID(ctx, id)
 	if err != nil {
 		return nil
 	}
-	return &doctor
+	return doctor
+}
+
+// GetSchedulesByDoctor groups all doctor schedules by doctor ID
+func (s *DoctorService) GetSchedulesByDoctor(ctx context.Context) map[string][]models.DoctorSchedule {
+	schedules, err := s.doctors.FindSchedules(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching doctor schedules", "error", err)
+	}
+
+	grouped := map[string][]models.DoctorSchedule{}
+	for _, schedule := range schedules {
+		grouped[schedule.DoctorID.String()] = append(grouped[schedule.DoctorID.String()], schedule)
+	}
+
+	return grouped
 }
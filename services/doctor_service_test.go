@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
+	"github.com/google/uuid"
+)
+
+func TestGetDoctorByID(t *testing.T) {
+	doctor := models.Doctor{ID: uuid.NewString(), Name: "Dr. Sari", Specialty: "General Practitioner"}
+	service := NewDoctorService(repositories.NewMemoryRepositories(doctor, models.Doctor{Name: "Dr. Budi"}).Doctors)
+
+	if found := service.GetDoctorByID(t.Context(), doctor.ID); found == nil || found.Name != "Dr. Sari" {
+		t.Errorf("GetDoctorByID = %+v, want Dr. Sari", found)
+	}
+	if service.GetDoctorByID(t.Context(), uuid.NewString()) != nil {
+		t.Error("GetDoctorByID found an unknown doctor")
+	}
+	if service.GetDoctorByID(t.Context(), "not-a-uuid") != nil {
+		t.Error("GetDoctorByID accepted an invalid ID")
+	}
+	if doctors := service.GetAllDoctors(t.Context()); len(doctors) != 2 {
+		t.Errorf("GetAllDoctors returned %d doctors, want 2", len(doctors))
+	}
+}
+
+func TestGetSchedulesByDoctor(t *testing.T) {
+	doctor := models.Doctor{ID: uuid.NewString(), Name: "Dr. Sari"}
+	doctorID := uuid.MustParse(doctor.ID)
+	repos := repositories.NewMemoryRepositoriesWithSchedules([]models.Doctor{doctor}, []models.DoctorSchedule{
+		{DoctorID: doctorID, Weekday: 3, StartTime: "08:00", EndTime: "12:00"},
+		{DoctorID: doctorID, Weekday: 1, StartTime: "13:00", EndTime: "17:00"},
+		{DoctorID: doctorID, Weekday: 1, StartTime: "08:00", EndTime: "12:00"},
+	})
+
+	schedules := NewDoctorService(repos.Doctors).GetSchedulesByDoctor(t.Context())[doctor.ID]
+	if len(schedules) != 3 {
+		t.Fatalf("got %d schedules, want 3", len(schedules))
+	}
+	if schedules[0].Weekday != 1 || schedules[0].StartTime != "08:00" || schedules[2].Weekday != 3 {
+		t.Errorf("schedules = %+v, want them by weekday and start time", schedules)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"gorm.io/gorm/clause"
+)
+
+// LoadDrugCatalog upserts the drugs of a CSV file with a "name,generic_name,form,strength,drug_class" header
+func LoadDrugCatalog(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open drug catalog: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	if _, err := reader.Read(); err != nil {
+		return 0, fmt.Errorf("failed to read drug catalog header: %w", err)
+	}
+
+	var drugs []models.Drug
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read drug catalog: %w", err)
+		}
+		if len(record) < 5 {
+			continue
+		}
+
+		drugs = append(drugs, models.Drug{
+			Name:        strings.TrimSpace(record[0]),
+			GenericName: strings.TrimSpace(record[1]),
+			Form:        strings.TrimSpace(record[2]),
+			Strength:    strings.TrimSpace(record[3]),
+			DrugClass:   strings.ToLower(strings.TrimSpace(record[4])),
+		})
+	}
+
+	if len(drugs) == 0 {
+		return 0, nil
+	}
+
+	err = config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"generic_name", "form", "strength", "drug_class"}),
+	}).CreateInBatches(&drugs, 500).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to save drug catalog: %w", err)
+	}
+
+	return len(drugs), nil
+}
+
+// SeedDrugCatalog loads the bundled catalog (or DRUGS_CSV_PATH) when the catalog is still empty
+func SeedDrugCatalog() {
+	var count int64
+	config.DB.Model(&models.Drug{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	loaded, err := LoadDrugCatalog(settings.Catalogs.DrugsCSVPath)
+	if err != nil {
+		slog.Error("Error loading drug catalog", "path", settings.Catalogs.DrugsCSVPath, "error", err)
+		return
+	}
+
+	slog.Info("Loaded drug catalog", "count", loaded, "path", settings.Catalogs.DrugsCSVPath)
+}
+
+// SearchDrugs finds drugs whose brand or generic name contains the query
+func SearchDrugs(query string, limit int) ([]models.Drug, error) {
+	pattern := "%" + strings.TrimSpace(query) + "%"
+
+	var drugs []models.Drug
+	err := config.DB.
+		Where("name ILIKE ? OR generic_name ILIKE ?", pattern, pattern).
+		Order("name ASC").
+		Limit(limit).
+		Find(&drugs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search drugs: %w", err)
+	}
+
+	return drugs, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/BeeCodingAI/triana-api/metrics"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// sendEmail posts the email to the relay, kind names the email in the notification metrics
+func sendEmail(ctx context.Context, kind string, email schemas.Email, token string) (result map[string]interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "email.send", attribute.String("email.kind", kind))
+	defer func() {
+		metrics.CountNotification(kind, err)
+		tracing.End(span, err)
+	}()
+
+	jsonData, err := json.Marshal(email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal email request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", settings.Email.ServiceURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header)) // the relay can join the trace
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, emailError(fmt.Errorf("failed to send HTTP request: %w", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, emailError(fmt.Errorf("error from email service: %s", resp.Status))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result, nil
+}
+
+// emailError reports a failure of the relay as an upstream error
+func emailError(err error) error {
+	return Upstream("email_unavailable", "the email could not be sent, try again later", err)
+}
+
+func readEmailTemplate(htmlFilePath string) string {
+	// Read the HTML file
+	htmlBytes, err := os.ReadFile(htmlFilePath)
+	if err != nil {
+		slog.Error("Failed to read email template", "path", htmlFilePath, "error", err)
+		os.Exit(1) // the templates ship with the binary
+	}
+
+	// Convert the file content to a string
+	return string(htmlBytes)
+}
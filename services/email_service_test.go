@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSendEmailJoinsTheTrace(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(sdktrace.NewTracerProvider()) })
+
+	var traceparent string
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"status": "sent"}`))
+	}))
+	defer relay.Close()
+
+	previous := settings
+	settings = config.Defaults()
+	settings.Email.ServiceURL = relay.URL
+	t.Cleanup(func() { settings = previous })
+
+	ctx, request := provider.Tracer("test").Start(context.Background(), "POST /session/:id")
+	if _, err := sendEmail(ctx, "queue", schemas.Email{To: "patient@example.com"}, "token"); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+	request.End()
+
+	ended := spans.Ended()
+	if len(ended) != 2 || ended[0].Name() != "email.send" {
+		t.Fatalf("got %d spans, want email.send and the request", len(ended))
+	}
+	email := ended[0]
+	if email.Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("email.send is not a child of the request span")
+	}
+	if traceparent == "" || traceparent[3:35] != request.SpanContext().TraceID().String() {
+		t.Errorf("relay got traceparent %q, want the trace %s", traceparent, request.SpanContext().TraceID())
+	}
+}
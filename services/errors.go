@@ -0,0 +1,71 @@
+package services
+
+// ErrorKind tells the error middleware which status code a service error maps to
+type ErrorKind string
+
+const (
+	KindNotFound     ErrorKind = "not_found"    // 404
+	KindValidation   ErrorKind = "validation"   // 400
+	KindConflict     ErrorKind = "conflict"     // 409
+	KindUnauthorized ErrorKind = "unauthorized" // 401
+	KindUpstream     ErrorKind = "upstream"     // 502, Gemini, the email relay or the hospital system failed
+)
+
+// Error is a domain error with a machine-readable code, e.g. slot_unavailable, that clients can
+// switch on instead of parsing the message
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Details map[string]any // e.g. the failed tag per field, or the allergy conflicts of a prescription
+	Err     error          // the cause, never shown to the client for upstream errors
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithDetails returns a copy of the error with the given details
+func (e *Error) WithDetails(details map[string]any) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func NotFound(code string, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Validation(code string, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func Conflict(code string, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Unauthorized(code string, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+// Upstream wraps the failure of an external dependency, the client only sees the message
+func Upstream(code string, message string, err error) *Error {
+	return &Error{Kind: KindUpstream, Code: code, Message: message, Err: err}
+}
+
+var (
+	ErrUserNotFound        = NotFound("user_not_found", "user not found")
+	ErrDoctorNotFound      = NotFound("doctor_not_found", "doctor not found")
+	ErrSessionNotFound     = NotFound("session_not_found", "session not found")
+	ErrQueueNotFound       = NotFound("queue_not_found", "queue not found")
+	ErrAppointmentNotFound = NotFound("appointment_not_found", "appointment not found")
+	ErrInvalidOTP          = Unauthorized("invalid_otp", "invalid OTP")
+	ErrInvalidToken        = Unauthorized("invalid_token", "Invalid or missing token") // the signed token of an email link
+)
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	fhirICD10System       = "http://hl7.org/fhir/sid/icd-10"
+	fhirLOINCSystem       = "http://loinc.org"
+	fhirUCUMSystem        = "http://unitsofmeasure.org"
+	fhirActCodeSystem     = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	fhirObsCategorySystem = "http://terminology.hl7.org/CodeSystem/observation-category"
+	fhirCondClinicalSys   = "http://terminology.hl7.org/CodeSystem/condition-clinical"
+	fhirCondVerifySys     = "http://terminology.hl7.org/CodeSystem/condition-ver-status"
+	fhirCondCategorySys   = "http://terminology.hl7.org/CodeSystem/condition-category"
+	fhirNationalityURL    = "http://hl7.org/fhir/StructureDefinition/patient-nationality"
+)
+
+// fhirBaseURL is the base of the fullUrl of bundle entries
+func fhirBaseURL() string {
+	return strings.TrimRight(settings.App.APIBaseURL, "/") + "/fhir"
+}
+
+// fhirDateTime formats a timestamp, stored without time zone in local time, as a FHIR dateTime
+func fhirDateTime(t time.Time) string {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local).Format(time.RFC3339)
+}
+
+// GetFHIRPatient maps a user to a FHIR Patient
+func (s *UserService) GetFHIRPatient(ctx context.Context, userID uuid.UUID) (*schemas.FHIRPatient, error) {
+	user := s.GetUserByID(ctx, userID)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	patient := fhirPatient(user)
+	if err := ValidateFHIRResource(patient); err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// GetFHIREncounters returns the sessions of a user as a searchset Bundle of Encounters
+func (s *UserService) GetFHIREncounters(ctx context.Context, userID uuid.UUID) (*schemas.FHIRBundle, error) {
+	if s.GetUserByID(ctx, userID) == nil {
+		return nil, ErrUserNotFound
+	}
+
+	sessions, queues, _, err := fhirSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []schemas.FHIRResource
+	for i := range sessions {
+		resources = append(resources, fhirEncounter(&sessions[i], queues[sessions[i].ID]))
+	}
+
+	return fhirSearchBundle(resources)
+}
+
+// GetFHIRPatientEverything returns the Patient with all their Encounters, Observations, Conditions and Appointments
+func (s *UserService) GetFHIRPatientEverything(ctx context.Context, userID uuid.UUID) (*schemas.FHIRBundle, error) {
+	user := s.GetUserByID(ctx, userID)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	sessions, queues, appointments, err := fhirSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := []schemas.FHIRResource{fhirPatient(user)}
+	for i := range sessions {
+		session := &sessions[i]
+		resources = append(resources, fhirEncounter(session, queues[session.ID]))
+		for _, observation := range fhirObservations(session) {
+			resources = append(resources, observation)
+		}
+		for _, condition := range fhirConditions(session) {
+			resources = append(resources, condition)
+		}
+		for j := range queues[session.ID] {
+			resources = append(resources, fhirQueueAppointment(&queues[session.ID][j], session))
+		}
+		for j := range appointments[session.ID] {
+			resources = append(resources, fhirBookedAppointment(&appointments[session.ID][j], session))
+		}
+	}
+
+	return fhirSearchBundle(resources)
+}
+
+// fhirSessions loads the sessions of a user with their diagnoses, queue entries and booked appointments
+func fhirSessions(ctx context.Context, userID uuid.UUID) ([]models.Session, map[uuid.UUID][]models.Queue, map[uuid.UUID][]models.Appointment, error) {
+	var sessions []models.Session
+	err := config.DB.WithContext(ctx).
+		Preload("ConsultationNote.Diagnoses").
+		Preload("CodedDiagnoses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("rank ASC")
+		}).
+		Preload("CodedDiagnoses.ICD10").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+
+	sessionIDs := make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+
+	queues := map[uuid.UUID][]models.Queue{}
+	appointments := map[uuid.UUID][]models.Appointment{}
+	if len(sessionIDs) == 0 {
+		return sessions, queues, appointments, nil
+	}
+
+	var queueList []models.Queue
+	if err := config.DB.WithContext(ctx).Preload("Doctor").Where("session_id IN ?", sessionIDs).Order("created_at ASC").Find(&queueList).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch queues: %w", err)
+	}
+	for _, queue := range queueList {
+		queues[queue.SessionID] = append(queues[queue.SessionID], queue)
+	}
+
+	var appointmentList []models.Appointment
+	if err := config.DB.WithContext(ctx).Preload("Doctor").Where("session_id IN ?", sessionIDs).Order("starts_at ASC").Find(&appointmentList).Error; err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch appointments: %w", err)
+	}
+	for _, appointment := range appointmentList {
+		appointments[appointment.SessionID] = append(appointments[appointment.SessionID], appointment)
+	}
+
+	return sessions, queues, appointments, nil
+}
+
+// fhirSearchBundle wraps resources in a searchset Bundle and validates all of them
+func fhirSearchBundle(resources []schemas.FHIRResource) (*schemas.FHIRBundle, error) {
+	total := len(resources)
+	bundle := schemas.FHIRBundle{
+		ResourceType: "Bundle",
+		ID:           uuid.NewString(),
+		Type:         "searchset",
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Total:        &total,
+	}
+
+	for _, resource := range resources {
+		bundle.Entry = append(bundle.Entry, schemas.FHIRBundleEntry{
+			FullURL:  fmt.Sprintf("%s/%s/%s", fhirBaseURL(), resource.GetResourceType(), resource.GetID()),
+			Resource: resource,
+		})
+	}
+
+	if err := ValidateFHIRResource(bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func fhirPatient(user *models.User) schemas.FHIRPatient {
+	patient := schemas.FHIRPatient{
+		ResourceType: "Patient",
+		ID:           user.ID.String(),
+		Identifier:   []schemas.FHIRIdentifier{{System: "urn:ietf:rfc:3986", Value: "urn:uuid:" + user.ID.String()}},
+		Name:         []schemas.FHIRHumanName{{Text: user.Name}},
+		Telecom:      []schemas.FHIRContactPoint{{System: "email", Value: user.Email}},
+		Gender:       fhirGender(user.Gender),
+		BirthDate:    fhirDate(user.DOB),
+	}
+
+	if user.Nationality != "" {
+		patient.Extension = []schemas.FHIRExtension{{
+			URL: fhirNationalityURL,
+			Extension: []schemas.FHIRExtension{{
+				URL:                  "code",
+				ValueCodeableConcept: &schemas.FHIRCodeableConcept{Text: user.Nationality},
+			}},
+		}}
+	}
+
+	return patient
+}
+
+func fhirGender(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "male", "m", "laki-laki":
+		return "male"
+	case "female", "f", "perempuan":
+		return "female"
+	case "":
+		return "unknown"
+	default:
+		return "other"
+	}
+}
+
+// fhirDate keeps the date part of a date column, which may be read back as a timestamp
+func fhirDate(date string) string {
+	if len(date) >= 10 {
+		return date[:10]
+	}
+	return date
+}
+
+func fhirEncounter(session *models.Session, queues []models.Queue) schemas.FHIREncounter {
+	encounter := schemas.FHIREncounter{
+		ResourceType: "Encounter",
+		ID:           session.ID.String(),
+		Status:       fhirEncounterStatus(session, queues),
+		Class:        schemas.FHIRCoding{System: fhirActCodeSystem, Code: "AMB", Display: "ambulatory"},
+		Subject:      &schemas.FHIRReference{Reference: "Patient/" + session.UserID.String()},
+		Period:       &schemas.FHIRPeriod{Start: fhirDateTime(session.CreatedAt)},
+	}
+
+	// the doctor the patient was last queued for
+	if len(queues) > 0 {
+		doctor := queues[len(queues)-1].Doctor
+		encounter.Participant = []schemas.FHIREncounterParticipant{{
+			Individual: &schemas.FHIRReference{Reference: "Practitioner/" + doctor.ID, Display: doctor.Name},
+		}}
+		encounter.ServiceType = &schemas.FHIRCodeableConcept{Text: doctor.Specialty}
+	}
+
+	if encounter.Status == "finished" {
+		encounter.Period.End = fhirDateTime(session.UpdatedAt)
+	}
+
+	if session.Prediagnosis != "" {
+		encounter.ReasonCode = []schemas.FHIRCodeableConcept{{Text: session.Prediagnosis}}
+	}
+
+	for i, condition := range fhirConditions(session) {
+		encounter.Diagnosis = append(encounter.Diagnosis, schemas.FHIREncounterDiagnosis{
+			Condition: schemas.FHIRReference{Reference: "Condition/" + condition.ID},
+			Rank:      i + 1,
+		})
+	}
+
+	return encounter
+}
+
+func fhirEncounterStatus(session *models.Session, queues []models.Queue) string {
+	if session.DoctorDiagnosis != "" {
+		return "finished"
+	}
+
+	if len(queues) > 0 {
+		latest := queues[len(queues)-1]
+		switch {
+		case latest.Status == models.QueueStatusCancelled || latest.Status == models.QueueStatusNoShow:
+			return "cancelled"
+		case latest.ArrivedAt != nil:
+			return "arrived"
+		}
+	}
+
+	if session.Prediagnosis != "" {
+		return "triaged"
+	}
+	return "in-progress"
+}
+
+func fhirObservations(session *models.Session) []schemas.FHIRObservation {
+	var observations []schemas.FHIRObservation
+	for _, vital := range vitalSigns {
+		value := vital.value(session)
+		if value == 0 {
+			continue
+		}
+
+		observations = append(observations, schemas.FHIRObservation{
+			ResourceType: "Observation",
+			ID:           fmt.Sprintf("%s-%s", session.ID, vital.key),
+			Status:       "final",
+			Category: []schemas.FHIRCodeableConcept{{
+				Coding: []schemas.FHIRCoding{{System: fhirObsCategorySystem, Code: "vital-signs", Display: "Vital Signs"}},
+			}},
+			Code: schemas.FHIRCodeableConcept{
+				Coding: []schemas.FHIRCoding{{System: fhirLOINCSystem, Code: vital.loinc, Display: vital.display}},
+				Text:   vital.display,
+			},
+			Subject:           &schemas.FHIRReference{Reference: "Patient/" + session.UserID.String()},
+			Encounter:         &schemas.FHIRReference{Reference: "Encounter/" + session.ID.String()},
+			EffectiveDateTime: fhirDateTime(session.CreatedAt),
+			ValueQuantity:     &schemas.FHIRQuantity{Value: float64(value), Unit: vital.unit, System: fhirUCUMSystem, Code: vital.unit},
+		})
+	}
+	return observations
+}
+
+// fhirConditions maps the doctor's diagnoses (confirmed) and the AI prediagnosis (provisional)
+func fhirConditions(session *models.Session) []schemas.FHIRCondition {
+	var conditions []schemas.FHIRCondition
+
+	newCondition := func(id string, code *schemas.FHIRCodeableConcept, verification string, recorded time.Time) schemas.FHIRCondition {
+		return schemas.FHIRCondition{
+			ResourceType: "Condition",
+			ID:           id,
+			ClinicalStatus: &schemas.FHIRCodeableConcept{
+				Coding: []schemas.FHIRCoding{{System: fhirCondClinicalSys, Code: "active"}},
+			},
+			VerificationStatus: &schemas.FHIRCodeableConcept{
+				Coding: []schemas.FHIRCoding{{System: fhirCondVerifySys, Code: verification}},
+			},
+			Category: []schemas.FHIRCodeableConcept{{
+				Coding: []schemas.FHIRCoding{{System: fhirCondCategorySys, Code: "encounter-diagnosis", Display: "Encounter Diagnosis"}},
+			}},
+			Code:         code,
+			Subject:      schemas.FHIRReference{Reference: "Patient/" + session.UserID.String()},
+			Encounter:    &schemas.FHIRReference{Reference: "Encounter/" + session.ID.String()},
+			RecordedDate: fhirDateTime(recorded),
+		}
+	}
+
+	if session.ConsultationNote != nil && len(session.ConsultationNote.Diagnoses) > 0 {
+		for _, diagnosis := range session.ConsultationNote.Diagnoses {
+			code := &schemas.FHIRCodeableConcept{Text: diagnosis.Description}
+			if diagnosis.ICD10Code != "" {
+				code.Coding = []schemas.FHIRCoding{{System: fhirICD10System, Code: diagnosis.ICD10Code}}
+			}
+			conditions = append(conditions, newCondition(diagnosis.ID.String(), code, "confirmed", session.ConsultationNote.UpdatedAt))
+		}
+	} else if session.DoctorDiagnosis != "" {
+		code := &schemas.FHIRCodeableConcept{Text: session.DoctorDiagnosis, Coding: fhirCodings(session.CodedDiagnoses, models.DiagnosisSourceDoctor)}
+		conditions = append(conditions, newCondition(session.ID.String()+"-diagnosis", code, "confirmed", session.UpdatedAt))
+	}
+
+	if session.Prediagnosis != "" {
+		code := &schemas.FHIRCodeableConcept{Text: session.Prediagnosis, Coding: fhirCodings(session.CodedDiagnoses, models.DiagnosisSourceAI)}
+		conditions = append(conditions, newCondition(session.ID.String()+"-prediagnosis", code, "provisional", session.CreatedAt))
+	}
+
+	return conditions
+}
+
+func fhirCodings(coded []models.CodedDiagnosis, source string) []schemas.FHIRCoding {
+	var codings []schemas.FHIRCoding
+	for _, diagnosis := range coded {
+		if diagnosis.Source == source {
+			codings = append(codings, schemas.FHIRCoding{System: fhirICD10System, Code: diagnosis.Code, Display: diagnosis.ICD10.Title})
+		}
+	}
+	return codings
+}
+
+func fhirQueueAppointment(queue *models.Queue, session *models.Session) schemas.FHIRAppointment {
+	status := "booked"
+	switch {
+	case queue.Status == models.QueueStatusCancelled || queue.Status == models.QueueStatusTransferred:
+		status = "cancelled"
+	case queue.Status == models.QueueStatusNoShow:
+		status = "noshow"
+	case session.DoctorDiagnosis != "":
+		status = "fulfilled"
+	case queue.ArrivedAt != nil:
+		status = "arrived"
+	}
+
+	start := queue.CreatedAt
+	if queue.ArrivedAt != nil {
+		start = *queue.ArrivedAt
+	}
+
+	return schemas.FHIRAppointment{
+		ResourceType: "Appointment",
+		ID:           queue.ID.String(),
+		Status:       status,
+		ServiceType:  []schemas.FHIRCodeableConcept{{Text: queue.Doctor.Specialty}},
+		Description:  fmt.Sprintf("Queue number %d, room %s", queue.Number, queue.Doctor.Roomno),
+		Start:        fhirDateTime(start),
+		End:          fhirDateTime(start.Add(consultationDuration)),
+		Created:      fhirDateTime(queue.CreatedAt),
+		Participant:  fhirAppointmentParticipants(session.UserID, queue.Doctor),
+	}
+}
+
+func fhirBookedAppointment(appointment *models.Appointment, session *models.Session) schemas.FHIRAppointment {
+	status := "booked"
+	switch appointment.Status {
+	case models.AppointmentStatusCancelled:
+		status = "cancelled"
+	case models.AppointmentStatusNoShow:
+		status = "noshow"
+	case models.AppointmentStatusCheckedIn:
+		status = "arrived"
+	}
+
+	return schemas.FHIRAppointment{
+		ResourceType: "Appointment",
+		ID:           appointment.ID.String(),
+		Status:       status,
+		ServiceType:  []schemas.FHIRCodeableConcept{{Text: appointment.Doctor.Specialty}},
+		Description:  fmt.Sprintf("Appointment in room %s", appointment.Doctor.Roomno),
+		Start:        fhirDateTime(appointment.StartsAt),
+		End:          fhirDateTime(appointment.EndsAt),
+		Created:      fhirDateTime(appointment.CreatedAt),
+		Participant:  fhirAppointmentParticipants(session.UserID, appointment.Doctor),
+	}
+}
+
+func fhirAppointmentParticipants(userID uuid.UUID, doctor models.Doctor) []schemas.FHIRAppointmentParticipant {
+	return []schemas.FHIRAppointmentParticipant{
+		{Actor: &schemas.FHIRReference{Reference: "Patient/" + userID.String()}, Status: "accepted"},
+		{Actor: &schemas.FHIRReference{Reference: "Practitioner/" + doctor.ID, Display: doctor.Name}, Status: "accepted"},
+	}
+}
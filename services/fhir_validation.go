@@ -0,0 +1,290 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/schemas"
+)
+
+var ErrInvalidFHIRResource = errors.New("invalid FHIR resource")
+
+var (
+	fhirIDPattern        = regexp.MustCompile(`^[A-Za-z0-9\-.]{1,64}$`)
+	fhirReferencePattern = regexp.MustCompile(`^[A-Z][A-Za-z]+/[A-Za-z0-9\-.]{1,64}$`)
+)
+
+// R4 value sets of the coded elements we export
+var (
+	fhirGenders             = []string{"male", "female", "other", "unknown"}
+	fhirEncounterStatuses   = []string{"planned", "arrived", "triaged", "in-progress", "onleave", "finished", "cancelled", "entered-in-error", "unknown"}
+	fhirObservationStatuses = []string{"registered", "preliminary", "final", "amended", "corrected", "cancelled", "entered-in-error", "unknown"}
+	fhirClinicalStatuses    = []string{"active", "recurrence", "relapse", "inactive", "remission", "resolved"}
+	fhirVerifyStatuses      = []string{"unconfirmed", "provisional", "differential", "confirmed", "refuted", "entered-in-error"}
+	fhirAppointmentStatuses = []string{"proposed", "pending", "booked", "arrived", "fulfilled", "cancelled", "noshow", "entered-in-error", "checked-in", "waitlist"}
+	fhirParticipantStatuses = []string{"accepted", "declined", "tentative", "needs-action"}
+	fhirBundleTypes         = []string{"document", "message", "transaction", "transaction-response", "batch", "batch-response", "history", "searchset", "collection"}
+)
+
+// fhirValidator collects the issues of a resource, prefixed with the path of the element
+type fhirValidator struct {
+	issues []string
+}
+
+func (v *fhirValidator) fail(path string, format string, args ...interface{}) {
+	v.issues = append(v.issues, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *fhirValidator) required(path string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(path, "is required")
+	}
+}
+
+func (v *fhirValidator) code(path string, value string, valueSet []string) {
+	for _, allowed := range valueSet {
+		if value == allowed {
+			return
+		}
+	}
+	v.fail(path, "%q is not one of %s", value, strings.Join(valueSet, ", "))
+}
+
+func (v *fhirValidator) id(path string, value string) {
+	if !fhirIDPattern.MatchString(value) {
+		v.fail(path, "%q is not a valid id", value)
+	}
+}
+
+func (v *fhirValidator) reference(path string, reference *schemas.FHIRReference, required bool) {
+	if reference == nil || (reference.Reference == "" && reference.Display == "") {
+		if required {
+			v.fail(path, "is required")
+		}
+		return
+	}
+	if reference.Reference != "" && !fhirReferencePattern.MatchString(reference.Reference) {
+		v.fail(path+".reference", "%q is not a relative reference", reference.Reference)
+	}
+}
+
+func (v *fhirValidator) dateTime(path string, value string, required bool) time.Time {
+	if value == "" {
+		if required {
+			v.fail(path, "is required")
+		}
+		return time.Time{}
+	}
+	// a dateTime with a time must have a time zone, otherwise it is a partial date
+	for _, layout := range []string{time.RFC3339, "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	v.fail(path, "%q is not a valid dateTime", value)
+	return time.Time{}
+}
+
+func (v *fhirValidator) date(path string, value string) {
+	if value == "" {
+		return
+	}
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		v.fail(path, "%q is not a valid date", value)
+	}
+}
+
+func (v *fhirValidator) codeableConcept(path string, concept *schemas.FHIRCodeableConcept, required bool) {
+	if concept == nil || (len(concept.Coding) == 0 && concept.Text == "") {
+		if required {
+			v.fail(path, "is required")
+		}
+		return
+	}
+	for i, coding := range concept.Coding {
+		codingPath := fmt.Sprintf("%s.coding[%d]", path, i)
+		v.required(codingPath+".code", coding.Code)
+		if coding.System != "" && !strings.Contains(coding.System, ":") {
+			v.fail(codingPath+".system", "%q is not a URI", coding.System)
+		}
+	}
+}
+
+// codingCode returns the first code of a concept for value set checks
+func codingCode(concept *schemas.FHIRCodeableConcept) string {
+	if concept == nil || len(concept.Coding) == 0 {
+		return ""
+	}
+	return concept.Coding[0].Code
+}
+
+// ValidateFHIRResource checks a resource against the cardinalities, value sets and invariants of the R4 JSON structure
+func ValidateFHIRResource(resource schemas.FHIRResource) error {
+	v := &fhirValidator{}
+	v.resource(resource.GetResourceType(), resource)
+	if len(v.issues) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidFHIRResource, strings.Join(v.issues, "; "))
+	}
+	return nil
+}
+
+func (v *fhirValidator) resource(path string, resource schemas.FHIRResource) {
+	switch r := resource.(type) {
+	case schemas.FHIRPatient:
+		v.patient(path, r)
+	case schemas.FHIREncounter:
+		v.encounter(path, r)
+	case schemas.FHIRObservation:
+		v.observation(path, r)
+	case schemas.FHIRCondition:
+		v.condition(path, r)
+	case schemas.FHIRAppointment:
+		v.appointment(path, r)
+	case schemas.FHIRBundle:
+		v.bundle(path, r)
+	default:
+		v.fail(path, "unsupported resource %T", resource)
+	}
+}
+
+func (v *fhirValidator) resourceHeader(path string, resourceType string, expected string, id string) {
+	if resourceType != expected {
+		v.fail(path+".resourceType", "must be %s", expected)
+	}
+	v.id(path+".id", id)
+}
+
+func (v *fhirValidator) patient(path string, patient schemas.FHIRPatient) {
+	v.resourceHeader(path, patient.ResourceType, "Patient", patient.ID)
+	if patient.Gender != "" {
+		v.code(path+".gender", patient.Gender, fhirGenders)
+	}
+	v.date(path+".birthDate", patient.BirthDate)
+	for i, extension := range patient.Extension {
+		v.required(fmt.Sprintf("%s.extension[%d].url", path, i), extension.URL)
+	}
+}
+
+func (v *fhirValidator) encounter(path string, encounter schemas.FHIREncounter) {
+	v.resourceHeader(path, encounter.ResourceType, "Encounter", encounter.ID)
+	v.code(path+".status", encounter.Status, fhirEncounterStatuses)
+	v.required(path+".class.code", encounter.Class.Code)
+	v.reference(path+".subject", encounter.Subject, false)
+	for i, participant := range encounter.Participant {
+		v.reference(fmt.Sprintf("%s.participant[%d].individual", path, i), participant.Individual, false)
+	}
+	if encounter.Period != nil {
+		start := v.dateTime(path+".period.start", encounter.Period.Start, false)
+		end := v.dateTime(path+".period.end", encounter.Period.End, false)
+		// per-1: the start is before the end
+		if !start.IsZero() && !end.IsZero() && end.Before(start) {
+			v.fail(path+".period", "end is before start")
+		}
+	}
+	for i, diagnosis := range encounter.Diagnosis {
+		v.reference(fmt.Sprintf("%s.diagnosis[%d].condition", path, i), &diagnosis.Condition, true)
+	}
+}
+
+func (v *fhirValidator) observation(path string, observation schemas.FHIRObservation) {
+	v.resourceHeader(path, observation.ResourceType, "Observation", observation.ID)
+	v.code(path+".status", observation.Status, fhirObservationStatuses)
+	v.codeableConcept(path+".code", &observation.Code, true)
+	v.reference(path+".subject", observation.Subject, false)
+	v.reference(path+".encounter", observation.Encounter, false)
+	v.dateTime(path+".effectiveDateTime", observation.EffectiveDateTime, false)
+	if observation.ValueQuantity != nil && observation.ValueQuantity.Code != "" && observation.ValueQuantity.System == "" {
+		// qty-3: a code needs a system
+		v.fail(path+".valueQuantity", "code without system")
+	}
+}
+
+func (v *fhirValidator) condition(path string, condition schemas.FHIRCondition) {
+	v.resourceHeader(path, condition.ResourceType, "Condition", condition.ID)
+	v.reference(path+".subject", &condition.Subject, true)
+	v.reference(path+".encounter", condition.Encounter, false)
+	v.codeableConcept(path+".code", condition.Code, false)
+	v.codeableConcept(path+".clinicalStatus", condition.ClinicalStatus, false)
+	v.codeableConcept(path+".verificationStatus", condition.VerificationStatus, false)
+	if condition.ClinicalStatus != nil {
+		v.code(path+".clinicalStatus", codingCode(condition.ClinicalStatus), fhirClinicalStatuses)
+	}
+	if condition.VerificationStatus != nil {
+		v.code(path+".verificationStatus", codingCode(condition.VerificationStatus), fhirVerifyStatuses)
+	}
+	// con-5: no clinical status when entered in error, con-4/con-3: otherwise it is expected
+	verification := codingCode(condition.VerificationStatus)
+	if verification == "entered-in-error" && condition.ClinicalStatus != nil {
+		v.fail(path+".clinicalStatus", "must be empty when verificationStatus is entered-in-error")
+	}
+	if verification != "entered-in-error" && condition.ClinicalStatus == nil {
+		v.fail(path+".clinicalStatus", "is required")
+	}
+	v.dateTime(path+".recordedDate", condition.RecordedDate, false)
+}
+
+func (v *fhirValidator) appointment(path string, appointment schemas.FHIRAppointment) {
+	v.resourceHeader(path, appointment.ResourceType, "Appointment", appointment.ID)
+	v.code(path+".status", appointment.Status, fhirAppointmentStatuses)
+
+	if len(appointment.Participant) == 0 {
+		v.fail(path+".participant", "at least one participant is required")
+	}
+	for i, participant := range appointment.Participant {
+		participantPath := fmt.Sprintf("%s.participant[%d]", path, i)
+		v.code(participantPath+".status", participant.Status, fhirParticipantStatuses)
+		v.reference(participantPath+".actor", participant.Actor, false)
+	}
+
+	start := v.dateTime(path+".start", appointment.Start, false)
+	end := v.dateTime(path+".end", appointment.End, false)
+	v.dateTime(path+".created", appointment.Created, false)
+
+	// app-2: either both start and end are given or neither
+	if (appointment.Start == "") != (appointment.End == "") {
+		v.fail(path, "start and end must both be present or both be absent")
+	}
+	// app-3: only proposed, cancelled and waitlisted appointments may miss start and end
+	if appointment.Start == "" && appointment.Status != "proposed" && appointment.Status != "cancelled" && appointment.Status != "waitlist" {
+		v.fail(path+".start", "is required when status is %s", appointment.Status)
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		v.fail(path, "end is before start")
+	}
+}
+
+func (v *fhirValidator) bundle(path string, bundle schemas.FHIRBundle) {
+	if bundle.ResourceType != "Bundle" {
+		v.fail(path+".resourceType", "must be Bundle")
+	}
+	if bundle.ID != "" {
+		v.id(path+".id", bundle.ID)
+	}
+	v.code(path+".type", bundle.Type, fhirBundleTypes)
+	// bdl-1: total only for search or history sets
+	if bundle.Total != nil && bundle.Type != "searchset" && bundle.Type != "history" {
+		v.fail(path+".total", "only allowed for searchset and history bundles")
+	}
+
+	seen := map[string]bool{}
+	for i, entry := range bundle.Entry {
+		entryPath := fmt.Sprintf("%s.entry[%d]", path, i)
+		if entry.Resource == nil {
+			v.fail(entryPath+".resource", "is required")
+			continue
+		}
+		// bdl-7: fullUrl is unique within the bundle
+		if entry.FullURL != "" {
+			if seen[entry.FullURL] {
+				v.fail(entryPath+".fullUrl", "%q is duplicated", entry.FullURL)
+			}
+			seen[entry.FullURL] = true
+		} else if bundle.Type == "searchset" {
+			v.fail(entryPath+".fullUrl", "is required in a searchset")
+		}
+		v.resource(entryPath+".resource", entry.Resource)
+	}
+}
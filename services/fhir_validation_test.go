@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+)
+
+func testFHIRAppointment(session *models.Session) schemas.FHIRAppointment {
+	appointment := models.Appointment{
+		ID:        uuid.New(),
+		Doctor:    models.Doctor{ID: uuid.NewString(), Name: "Dr. Budi", Specialty: "General", Roomno: "A1"},
+		StartsAt:  time.Date(2025, 5, 2, 9, 0, 0, 0, time.Local),
+		EndsAt:    time.Date(2025, 5, 2, 9, 15, 0, 0, time.Local),
+		CreatedAt: time.Date(2025, 5, 1, 9, 0, 0, 0, time.Local),
+	}
+	return fhirBookedAppointment(&appointment, session)
+}
+
+func TestValidateFHIRResource(t *testing.T) {
+	session := testHL7Session()
+	session.Prediagnosis = "Common cold"
+
+	tests := []struct {
+		name     string
+		resource func() schemas.FHIRResource
+		valid    bool
+	}{
+		{"patient", func() schemas.FHIRResource { return fhirPatient(&session.User) }, true},
+		{"patient with invalid gender", func() schemas.FHIRResource {
+			patient := fhirPatient(&session.User)
+			patient.Gender = "M"
+			return patient
+		}, false},
+		{"patient with invalid birth date", func() schemas.FHIRResource {
+			patient := fhirPatient(&session.User)
+			patient.BirthDate = "17/05/1990"
+			return patient
+		}, false},
+		{"encounter", func() schemas.FHIRResource { return fhirEncounter(session, nil) }, true},
+		{"booked appointment", func() schemas.FHIRResource { return testFHIRAppointment(session) }, true},
+		{"booked appointment without start and end", func() schemas.FHIRResource {
+			appointment := testFHIRAppointment(session)
+			appointment.Start, appointment.End = "", ""
+			return appointment
+		}, false},
+		{"cancelled appointment without start and end", func() schemas.FHIRResource {
+			appointment := testFHIRAppointment(session)
+			appointment.Status = "cancelled"
+			appointment.Start, appointment.End = "", ""
+			return appointment
+		}, true},
+		{"appointment without participants", func() schemas.FHIRResource {
+			appointment := testFHIRAppointment(session)
+			appointment.Participant = nil
+			return appointment
+		}, false},
+		{"appointment ending before it starts", func() schemas.FHIRResource {
+			appointment := testFHIRAppointment(session)
+			appointment.Start, appointment.End = appointment.End, appointment.Start
+			return appointment
+		}, false},
+		{"condition", func() schemas.FHIRResource { return fhirConditions(session)[0] }, true},
+		{"condition entered in error with a clinical status", func() schemas.FHIRResource {
+			condition := fhirConditions(session)[0]
+			condition.VerificationStatus.Coding[0].Code = "entered-in-error"
+			return condition
+		}, false},
+		{"condition without a clinical status", func() schemas.FHIRResource {
+			condition := fhirConditions(session)[0]
+			condition.ClinicalStatus = nil
+			return condition
+		}, false},
+		{"searchset bundle", func() schemas.FHIRResource {
+			bundle, _ := fhirSearchBundle([]schemas.FHIRResource{fhirPatient(&session.User), fhirEncounter(session, nil)})
+			return *bundle
+		}, true},
+		{"bundle with a duplicate fullUrl", func() schemas.FHIRResource {
+			bundle, _ := fhirSearchBundle([]schemas.FHIRResource{fhirPatient(&session.User)})
+			bundle.Entry = append(bundle.Entry, bundle.Entry[0])
+			return *bundle
+		}, false},
+		{"collection bundle with a total", func() schemas.FHIRResource {
+			bundle, _ := fhirSearchBundle([]schemas.FHIRResource{fhirPatient(&session.User)})
+			bundle.Type = "collection"
+			return *bundle
+		}, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateFHIRResource(tt.resource())
+		if tt.valid && err != nil {
+			t.Errorf("%s: got %v, want valid", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidFHIRResource) {
+			t.Errorf("%s: got %v, want ErrInvalidFHIRResource", tt.name, err)
+		}
+	}
+}
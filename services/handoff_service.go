@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/utils"
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+)
+
+// languages the handoff summary can be written in, by Doctor.Language
+var handoffLanguages = map[string]string{
+	"id": "Indonesian",
+	"en": "English",
+}
+
+const handoffSummaryPrompt = `You are a triage nurse writing a handoff for the doctor who will see this patient. Read the patient data, the previous visits and the conversation between the patient and the triage assistant, then write a concise clinician-oriented summary in %s, using medical terminology.
+
+- chief_complaint: the main reason for the visit in a few words
+- hpi: history of present illness, onset, duration, character, severity and associated symptoms in 2 to 4 sentences
+- relevant_history: relevant chronic conditions, medications, allergies and previous visits, or an empty string when there is nothing relevant
+- red_flags: findings that need urgent attention, e.g. abnormal vitals or alarm symptoms, an empty list when there are none
+- differential: up to 3 likely diagnoses, most likely first, each with a short reasoning
+
+Only use the information given, do not invent findings.`
+
+var handoffSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"chief_complaint":  {Type: genai.TypeString},
+		"hpi":              {Type: genai.TypeString},
+		"relevant_history": {Type: genai.TypeString},
+		"red_flags":        {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString}},
+		"differential": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"diagnosis": {Type: genai.TypeString},
+					"reasoning": {Type: genai.TypeString},
+				},
+				Required: []string{"diagnosis", "reasoning"},
+			},
+		},
+	},
+	Required: []string{"chief_complaint", "hpi", "relevant_history", "red_flags", "differential"},
+}
+
+// GenerateHandoffSummary writes the summary of a session for a doctor in the doctor's language and stores it
+func (s *SessionService) GenerateHandoffSummary(ctx context.Context, sessionID string, doctorID string) (*models.HandoffSummary, error) {
+	session, err := s.GetSessionData(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	doctor := s.doctors.GetDoctorByID(ctx, doctorID)
+	if doctor == nil {
+		return nil, ErrDoctorNotFound
+	}
+
+	language := doctor.Language
+	if _, ok := handoffLanguages[language]; !ok {
+		language = "id"
+	}
+
+	vitals := formatVitals(&session)
+	prompt := fmt.Sprintf(
+		"Patient data:\nAge: %s\nGender: %s\n%s\n%s\nPrevious visits:\n%s\nConversation:\n%s",
+		utils.DateToAgeString(session.User.DOB),
+		session.User.Gender,
+		vitals,
+		formatProfile(GetPatientProfile(session.UserID)),
+		formatHistory(s.GetHistory(ctx, &session)),
+		buildTranscript(session.Messages),
+	)
+
+	text, err := generateJSON(ctx, "handoff_summary", fmt.Sprintf(handoffSummaryPrompt, handoffLanguages[language]), prompt, handoffSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	var output schemas.HandoffSummaryOutput
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		return nil, llmError(fmt.Errorf("failed to parse handoff summary: %w", err))
+	}
+
+	doctorUUID, _ := uuid.Parse(doctor.ID)
+	now := time.Now()
+
+	var summary models.HandoffSummary
+	err = config.DB.WithContext(ctx).Where("session_id = ?", session.ID).First(&summary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		summary = models.HandoffSummary{SessionID: session.ID, CreatedAt: now}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch handoff summary: %w", err)
+	}
+
+	summary.DoctorID = doctorUUID
+	summary.Language = language
+	summary.ChiefComplaint = output.ChiefComplaint
+	summary.HPI = output.HPI
+	summary.RelevantHistory = output.RelevantHistory
+	summary.Vitals = vitals
+	summary.RedFlags = output.RedFlags
+	summary.Differential = nil
+	for _, item := range output.Differential {
+		summary.Differential = append(summary.Differential, models.DifferentialDiagnosis{Diagnosis: item.Diagnosis, Reasoning: item.Reasoning})
+	}
+	summary.UpdatedAt = now
+
+	if err := config.DB.WithContext(ctx).Save(&summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to save handoff summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// GenerateHandoffSummaryAsync runs the generation in the background so the patient does not wait for it,
+// it stays in the trace of the request but is not cancelled when the request ends
+func (s *SessionService) GenerateHandoffSummaryAsync(ctx context.Context, sessionID string, doctorID string) {
+	ctx = context.WithoutCancel(ctx)
+	runInBackground(func() {
+		if _, err := s.GenerateHandoffSummary(ctx, sessionID, doctorID); err != nil {
+			slog.ErrorContext(ctx, "Error generating handoff summary", "session_id", sessionID, "error", err)
+		}
+	})
+}
+
+func GetHandoffSummary(ctx context.Context, sessionID uuid.UUID) *models.HandoffSummary {
+	var summary models.HandoffSummary
+	if err := config.DB.WithContext(ctx).Where("session_id = ?", sessionID).First(&summary).Error; err != nil {
+		return nil
+	}
+	return &summary
+}
+
+func formatVitals(session *models.Session) string {
+	return fmt.Sprintf(
+		"Weight: %.1f kg, Height: %.1f cm, Heart rate: %.0f bpm, Body temperature: %.1f °C",
+		session.Weight, session.Height, session.Heartrate, session.Bodytemp,
+	)
+}
+
+// formatHistory renders the previous sessions with both the AI and the doctor's diagnosis
+func formatHistory(history []models.Session) string {
+	if len(history) == 0 {
+		return "No previous sessions found.\n"
+	}
+
+	var lines []string
+	for _, session := range history {
+		lines = append(lines, fmt.Sprintf(
+			"[%s] Prediagnosis: %s, Doctor diagnosis: %s\n",
+			session.CreatedAt.Format("2006-01-02"),
+			session.Prediagnosis,
+			session.DoctorDiagnosis,
+		))
+	}
+	return strings.Join(lines, "")
+}
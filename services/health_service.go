@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/migrations"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"gorm.io/gorm"
+)
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthCheck probes a dependency, Required checks make the API unavailable when they fail,
+// the others only degrade it, e.g. the registration still works while Gemini is down
+type HealthCheck struct {
+	Name     string
+	Required bool
+	Check    func(ctx context.Context) error
+}
+
+// cachedCheck keeps the last result of a check so frequent probes don't hit the dependencies
+type cachedCheck struct {
+	HealthCheck
+	mu     sync.Mutex
+	status *schemas.DependencyStatus
+}
+
+type HealthService struct {
+	checks  []*cachedCheck
+	ttl     time.Duration
+	timeout time.Duration
+}
+
+// NewHealthService runs the checks at most once per ttl, each within the timeout
+func NewHealthService(ttl time.Duration, timeout time.Duration, checks ...HealthCheck) *HealthService {
+	service := &HealthService{ttl: ttl, timeout: timeout}
+	for _, check := range checks {
+		service.checks = append(service.checks, &cachedCheck{HealthCheck: check})
+	}
+	return service
+}
+
+// DefaultHealthChecks checks Postgres and its migration version, which the API needs,
+// and the reachability of Gemini and the email relay
+func DefaultHealthChecks(db *gorm.DB) []HealthCheck {
+	return []HealthCheck{
+		{Name: "database", Required: true, Check: func(ctx context.Context) error { return checkDatabase(ctx, db) }},
+		{Name: "migrations", Required: true, Check: func(ctx context.Context) error { return checkMigrations(ctx, db) }},
+		{Name: "llm", Check: checkLLM},
+		{Name: "email", Check: checkEmailRelay},
+	}
+}
+
+// Readiness runs the checks concurrently and reports the status of every dependency
+func (s *HealthService) Readiness(ctx context.Context) schemas.HealthReport {
+	statuses := make([]schemas.DependencyStatus, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = s.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := schemas.HealthReport{Status: HealthStatusOK, Checks: map[string]schemas.DependencyStatus{}}
+	for i, check := range s.checks {
+		status := statuses[i]
+		report.Checks[check.Name] = status
+		if status.Status == "up" {
+			continue
+		}
+		if check.Required {
+			report.Status = HealthStatusUnavailable
+		} else if report.Status == HealthStatusOK {
+			report.Status = HealthStatusDegraded
+		}
+	}
+	return report
+}
+
+// run returns the cached result while it is fresh, concurrent probes wait for the same run
+func (s *HealthService) run(ctx context.Context, check *cachedCheck) schemas.DependencyStatus {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	if check.status != nil && time.Since(check.status.CheckedAt) < s.ttl {
+		return *check.status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := check.Check(ctx)
+	status := schemas.DependencyStatus{
+		Status:    "up",
+		Required:  check.Required,
+		LatencyMS: time.Since(started).Milliseconds(),
+		CheckedAt: started,
+	}
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err() // the check ignored its context
+	}
+	if err != nil {
+		status.Status = "down"
+		status.Error = err.Error()
+	}
+
+	check.status = &status
+	return status
+}
+
+func checkDatabase(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkMigrations fails while the database is behind the migrations of this binary,
+// a newer schema is fine during a rolling deploy
+func checkMigrations(ctx context.Context, db *gorm.DB) error {
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+	version, err := migrations.Version(db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("database is at version %d, expected %d", version, latest)
+	}
+	return nil
+}
+
+// checkLLM looks up the configured model, which needs a valid API key but costs no tokens
+func checkLLM(ctx context.Context) error {
+	client, err := newLLMClient(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = client.Models.Get(ctx, llmModel(), nil)
+	return err
+}
+
+// checkEmailRelay connects to the relay without sending anything
+func checkEmailRelay(ctx context.Context) error {
+	relay, err := url.Parse(settings.Email.ServiceURL)
+	if err != nil {
+		return err
+	}
+
+	port := relay.Port()
+	if port == "" {
+		port = "80"
+		if relay.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(relay.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadinessStatus(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name   string
+		checks []HealthCheck
+		want   string
+	}{
+		{"all up", []HealthCheck{{Name: "database", Required: true, Check: up}, {Name: "llm", Check: up}}, HealthStatusOK},
+		{"optional down", []HealthCheck{{Name: "database", Required: true, Check: up}, {Name: "llm", Check: down}}, HealthStatusDegraded},
+		{"required down", []HealthCheck{{Name: "database", Required: true, Check: down}, {Name: "llm", Check: down}}, HealthStatusUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewHealthService(time.Minute, time.Second, tt.checks...).Readiness(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("status = %s, want %s", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d checks, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestReadinessReportsTheError(t *testing.T) {
+	service := NewHealthService(time.Minute, time.Second, HealthCheck{Name: "email", Check: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}})
+
+	status := service.Readiness(context.Background()).Checks["email"]
+	if status.Status != "down" || status.Error != "connection refused" || status.Required {
+		t.Errorf("email = %+v, want an optional check down with its error", status)
+	}
+}
+
+func TestReadinessCachesResults(t *testing.T) {
+	var calls atomic.Int32
+	check := HealthCheck{Name: "llm", Check: func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}}
+
+	service := NewHealthService(time.Minute, time.Second, check)
+	for i := 0; i < 3; i++ {
+		service.Readiness(context.Background())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("check ran %d times within the TTL, want once", calls.Load())
+	}
+
+	uncached := NewHealthService(0, time.Second, check)
+	calls.Store(0)
+	for i := 0; i < 3; i++ {
+		uncached.Readiness(context.Background())
+	}
+	if calls.Load() != 3 {
+		t.Errorf("check ran %d times without a TTL, want 3", calls.Load())
+	}
+}
+
+func TestReadinessTimesOutSlowChecks(t *testing.T) {
+	service := NewHealthService(time.Minute, 20*time.Millisecond, HealthCheck{Name: "database", Required: true, Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	started := time.Now()
+	report := service.Readiness(context.Background())
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("readiness took %s, want about the timeout", elapsed)
+	}
+	if report.Status != HealthStatusUnavailable || report.Checks["database"].Error == "" {
+		t.Errorf("report = %+v, want the database down with a timeout", report)
+	}
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+)
+
+const hl7Version = "2.5"
+
+var hl7Escaper = strings.NewReplacer(`\`, `\E\`, "|", `\F\`, "^", `\S\`, "~", `\R\`, "&", `\T\`, "\r", " ", "\n", " ")
+
+// hl7Escape escapes the delimiters in a text value
+func hl7Escape(value string) string {
+	return hl7Escaper.Replace(value)
+}
+
+// hl7Components joins already escaped components of a field
+func hl7Components(components ...string) string {
+	return strings.TrimRight(strings.Join(components, "^"), "^")
+}
+
+func hl7Timestamp(t time.Time) string {
+	return t.Format("20060102150405")
+}
+
+// hl7Segment builds a segment from its numbered fields, missing fields are left empty.
+// In MSH the field separator is MSH-1, so MSH-2 is the first field after the segment name
+func hl7Segment(name string, fields map[int]string) string {
+	offset := 0
+	if name == "MSH" {
+		offset = 1
+	}
+
+	last := 0
+	for index := range fields {
+		if index-offset > last {
+			last = index - offset
+		}
+	}
+
+	values := make([]string, last+1)
+	values[0] = name
+	for index, value := range fields {
+		values[index-offset] = value
+	}
+	return strings.Join(values, "|")
+}
+
+// hl7Message is an outbound message with the control ID used to match the acknowledgment
+type hl7Message struct {
+	ControlID string
+	Text      string
+}
+
+func newHL7Message(messageType string, segments ...string) hl7Message {
+	controlID := strings.ReplaceAll(uuid.NewString(), "-", "")[:20]
+
+	msh := hl7Segment("MSH", map[int]string{
+		2:  `^~\&`,
+		3:  "TRIANA",
+		4:  "CLINIC",
+		5:  hl7Escape(settings.HL7.ReceivingApplication),
+		6:  hl7Escape(settings.HL7.ReceivingFacility),
+		7:  hl7Timestamp(time.Now()),
+		9:  messageType,
+		10: controlID,
+		11: "P",
+		12: hl7Version,
+	})
+
+	return hl7Message{ControlID: controlID, Text: strings.Join(append([]string{msh}, segments...), "\r")}
+}
+
+func hl7Gender(gender string) string {
+	switch fhirGender(gender) {
+	case "male":
+		return "M"
+	case "female":
+		return "F"
+	case "other":
+		return "O"
+	}
+	return "U"
+}
+
+// hl7PID identifies the patient by the user ID, the name is sent as a single family name component
+func hl7PID(user *models.User) string {
+	return hl7Segment("PID", map[int]string{
+		1:  "1",
+		3:  hl7Components(user.ID.String(), "", "", "TRIANA", "MR"),
+		5:  hl7Escape(user.Name),
+		7:  strings.ReplaceAll(fhirDate(user.DOB), "-", ""),
+		8:  hl7Gender(user.Gender),
+		13: hl7Components("", "NET", "Internet", hl7Escape(user.Email)),
+		28: hl7Escape(user.Nationality),
+	})
+}
+
+// hl7PV1 is the outpatient visit of a session, with the attending doctor when known
+func hl7PV1(session *models.Session, doctor *models.Doctor) string {
+	fields := map[int]string{
+		1:  "1",
+		2:  "O",
+		19: session.ID.String(),
+		44: hl7Timestamp(session.CreatedAt),
+	}
+	if doctor != nil {
+		fields[3] = hl7Components(hl7Escape(doctor.Roomno), "", "", "CLINIC")
+		fields[7] = hl7Components(doctor.ID, hl7Escape(doctor.Name))
+		fields[10] = hl7Escape(doctor.Specialty)
+	}
+	return hl7Segment("PV1", fields)
+}
+
+// buildADTMessage builds an ADT^A04 (patient registered) or ADT^A08 (patient information updated)
+func buildADTMessage(event string, session *models.Session, doctor *models.Doctor, allergies []models.PatientAllergy, diagnoses []hl7Diagnosis) hl7Message {
+	segments := []string{
+		hl7Segment("EVN", map[int]string{1: event, 2: hl7Timestamp(time.Now())}),
+		hl7PID(&session.User),
+		hl7PV1(session, doctor),
+	}
+
+	for i, allergy := range allergies {
+		segments = append(segments, hl7Segment("AL1", map[int]string{
+			1: fmt.Sprint(i + 1),
+			3: hl7Components("", hl7Escape(allergy.Substance)),
+			4: hl7AllergySeverity(allergy.Severity),
+			5: hl7Escape(allergy.Reaction),
+		}))
+	}
+
+	for i, diagnosis := range diagnoses {
+		segments = append(segments, hl7Segment("DG1", map[int]string{
+			1: fmt.Sprint(i + 1),
+			3: hl7Components(hl7Escape(diagnosis.Code), hl7Escape(diagnosis.Description), hl7CodingSystem(diagnosis.Code)),
+			5: hl7Timestamp(diagnosis.RecordedAt),
+			6: diagnosis.Type,
+		}))
+	}
+
+	// ADT^A04 and ADT^A08 share the ADT_A01 message structure
+	return newHL7Message("ADT^"+event+"^ADT_A01", segments...)
+}
+
+// hl7Diagnosis is a DG1 segment, type W (working) for the AI prediagnosis and F (final) for the doctor's diagnosis
+type hl7Diagnosis struct {
+	Code        string
+	Description string
+	Type        string
+	RecordedAt  time.Time
+}
+
+func hl7CodingSystem(code string) string {
+	if code == "" {
+		return ""
+	}
+	return "I10"
+}
+
+func hl7AllergySeverity(severity string) string {
+	switch severity {
+	case "mild":
+		return "MI"
+	case "moderate":
+		return "MO"
+	case "severe":
+		return "SV"
+	}
+	return "U"
+}
+
+// buildORUMessage builds an ORU^R01 carrying the vitals of a session as LOINC coded observations
+func buildORUMessage(session *models.Session) hl7Message {
+	observedAt := hl7Timestamp(session.CreatedAt)
+	segments := []string{
+		hl7PID(&session.User),
+		hl7PV1(session, nil),
+		hl7Segment("OBR", map[int]string{
+			1: "1",
+			3: session.ID.String(),
+			4: hl7Components("85353-1", "Vital signs panel", "LN"),
+			7: observedAt,
+		}),
+	}
+
+	index := 0
+	for _, vital := range vitalSigns {
+		value := vital.value(session)
+		if value == 0 {
+			continue
+		}
+		index++
+		segments = append(segments, hl7Segment("OBX", map[int]string{
+			1:  fmt.Sprint(index),
+			2:  "NM",
+			3:  hl7Components(vital.loinc, vital.display, "LN"),
+			5:  strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), "."),
+			6:  hl7Components(hl7Escape(vital.unit), "", "UCUM"),
+			11: "F",
+			14: observedAt,
+		}))
+	}
+
+	return newHL7Message("ORU^R01^ORU_R01", segments...)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+)
+
+var (
+	hl7SenderMutex sync.RWMutex
+	hl7Sender      HL7Sender // nil while the HL7 interface is disabled
+)
+
+// SetHL7Sender plugs in the transport for outbound HL7 messages, nil disables the interface
+func SetHL7Sender(sender HL7Sender) {
+	hl7SenderMutex.Lock()
+	defer hl7SenderMutex.Unlock()
+	hl7Sender = sender
+}
+
+func getHL7Sender() HL7Sender {
+	hl7SenderMutex.RLock()
+	defer hl7SenderMutex.RUnlock()
+	return hl7Sender
+}
+
+// sendHL7 delivers a message and checks its acknowledgment
+func sendHL7(sender HL7Sender, message hl7Message) error {
+	ack, err := sender.Send(message.Text)
+	if err != nil {
+		return err
+	}
+	return checkHL7Ack(ack, message.ControlID)
+}
+
+// dispatchHL7 builds and sends messages in the background so requests never wait for the hospital system,
+// it stays in the trace of the request but is not cancelled when the request ends
+func dispatchHL7(ctx context.Context, description string, build func(ctx context.Context) ([]hl7Message, error)) {
+	sender := getHL7Sender()
+	if sender == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	runInBackground(func() {
+		messages, err := build(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error building HL7 message", "description", description, "error", err)
+			return
+		}
+		for _, message := range messages {
+			if err := sendHL7(sender, message); err != nil {
+				slog.ErrorContext(ctx, "Error sending HL7 message", "description", description, "control_id", message.ControlID, "error", err)
+			}
+		}
+	})
+}
+
+// NotifyHL7SessionCreated sends ADT^A04 for the registration and ORU^R01 with the vitals of a new session
+func (s *SessionService) NotifyHL7SessionCreated(ctx context.Context, sessionID uuid.UUID) {
+	dispatchHL7(ctx, "registration", func(ctx context.Context) ([]hl7Message, error) {
+		session, err := s.hl7Session(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		allergies := GetPatientAllergies(session.UserID)
+		return []hl7Message{
+			buildADTMessage("A04", session, nil, allergies, nil),
+			buildORUMessage(session),
+		}, nil
+	})
+}
+
+// NotifyHL7SessionUpdated sends ADT^A08 with the current doctor, allergies and diagnoses of a session
+func (s *SessionService) NotifyHL7SessionUpdated(ctx context.Context, sessionID uuid.UUID) {
+	dispatchHL7(ctx, "update", func(ctx context.Context) ([]hl7Message, error) {
+		session, err := s.hl7Session(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		return []hl7Message{
+			buildADTMessage("A08", session, s.treatingDoctor(ctx, *session), GetPatientAllergies(session.UserID), hl7Diagnoses(session)),
+		}, nil
+	})
+}
+
+// NotifyHL7PatientUpdated sends ADT^A08 for the latest session of a user, e.g. after a profile change
+func (s *SessionService) NotifyHL7PatientUpdated(ctx context.Context, userID uuid.UUID) {
+	if getHL7Sender() == nil {
+		return
+	}
+
+	var session models.Session
+	if err := config.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&session).Error; err != nil {
+		return // nothing was registered in the hospital system yet
+	}
+	s.NotifyHL7SessionUpdated(ctx, session.ID)
+}
+
+func (s *SessionService) hl7Session(ctx context.Context, sessionID uuid.UUID) (*models.Session, error) {
+	session, err := s.GetSessionData(ctx, sessionID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch session: %w", err)
+	}
+	return &session, nil
+}
+
+// hl7Diagnoses lists the doctor's diagnoses (final) or else the AI prediagnosis (working)
+func hl7Diagnoses(session *models.Session) []hl7Diagnosis {
+	var diagnoses []hl7Diagnosis
+	for _, condition := range fhirConditions(session) {
+		if condition.Code == nil {
+			continue
+		}
+
+		diagnosis := hl7Diagnosis{Description: condition.Code.Text, Type: "F", RecordedAt: session.UpdatedAt}
+		if codingCode(condition.VerificationStatus) == "provisional" {
+			if len(diagnoses) > 0 {
+				continue // the doctor's diagnosis replaces the prediagnosis
+			}
+			diagnosis.Type = "W"
+			diagnosis.RecordedAt = session.CreatedAt
+		}
+		if len(condition.Code.Coding) > 0 {
+			diagnosis.Code = condition.Code.Coding[0].Code
+		}
+		diagnoses = append(diagnoses, diagnosis)
+	}
+	return diagnoses
+}
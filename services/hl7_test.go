@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+)
+
+func testHL7Session() *models.Session {
+	user := models.User{ID: uuid.New(), Name: "Mario Rossi", Email: "mario@example.com", Gender: "Male", DOB: "1990-05-17", Nationality: "Italian"}
+	return &models.Session{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		User:      user,
+		Weight:    52,
+		Height:    170,
+		Heartrate: 80,
+		Bodytemp:  37.5,
+		CreatedAt: time.Date(2025, 5, 1, 9, 30, 0, 0, time.Local),
+	}
+}
+
+func segmentsByName(message string) map[string][]string {
+	segments := map[string][]string{}
+	for _, segment := range strings.Split(message, "\r") {
+		name := strings.SplitN(segment, "|", 2)[0]
+		segments[name] = append(segments[name], segment)
+	}
+	return segments
+}
+
+func TestMLLPSenderIsAcknowledgedByStub(t *testing.T) {
+	stub, err := StartMLLPStub("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start stub: %v", err)
+	}
+	defer stub.Close()
+
+	sender := NewMLLPSender(stub.Address(), 2*time.Second)
+	session := testHL7Session()
+	allergies := []models.PatientAllergy{{Substance: "Penicillin", Severity: "severe"}}
+
+	for _, message := range []hl7Message{
+		buildADTMessage("A04", session, nil, allergies, nil),
+		buildORUMessage(session),
+	} {
+		if err := sendHL7(sender, message); err != nil {
+			t.Fatalf("message %s was not acknowledged: %v", message.ControlID, err)
+		}
+	}
+
+	received := stub.Messages()
+	if len(received) != 2 {
+		t.Fatalf("stub received %d messages, want 2", len(received))
+	}
+
+	adt := segmentsByName(received[0])
+	if !strings.Contains(adt["MSH"][0], "|ADT^A04^ADT_A01|") {
+		t.Errorf("MSH does not carry ADT^A04: %s", adt["MSH"][0])
+	}
+	if !strings.Contains(adt["PID"][0], session.UserID.String()+"^^^TRIANA^MR") || !strings.Contains(adt["PID"][0], "|19900517|M|") {
+		t.Errorf("unexpected PID: %s", adt["PID"][0])
+	}
+	if len(adt["AL1"]) != 1 || !strings.Contains(adt["AL1"][0], "^Penicillin|SV") {
+		t.Errorf("unexpected AL1: %v", adt["AL1"])
+	}
+
+	oru := segmentsByName(received[1])
+	if len(oru["OBX"]) != 4 {
+		t.Fatalf("ORU has %d OBX segments, want 4", len(oru["OBX"]))
+	}
+	if !strings.HasPrefix(oru["OBX"][3], "OBX|4|NM|8310-5^Body temperature^LN||37.5|Cel^^UCUM|") {
+		t.Errorf("unexpected temperature OBX: %s", oru["OBX"][3])
+	}
+}
+
+func TestMLLPSenderReportsRejection(t *testing.T) {
+	stub, err := StartMLLPStub("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start stub: %v", err)
+	}
+	defer stub.Close()
+	stub.AckCode = "AE"
+
+	err = sendHL7(NewMLLPSender(stub.Address(), 2*time.Second), buildORUMessage(testHL7Session()))
+	if !errors.Is(err, ErrHL7Rejected) {
+		t.Fatalf("got %v, want ErrHL7Rejected", err)
+	}
+}
+
+func TestCheckHL7AckControlID(t *testing.T) {
+	ack := "MSH|^~\\&|HIS|HOSPITAL|TRIANA|CLINIC|20250501093000||ACK|1|P|2.5\rMSA|AA|other"
+	if err := checkHL7Ack(ack, "expected"); !errors.Is(err, ErrHL7Rejected) {
+		t.Fatalf("got %v, want ErrHL7Rejected for a mismatched control ID", err)
+	}
+	if err := checkHL7Ack(strings.Replace(ack, "other", "expected", 1), "expected"); err != nil {
+		t.Fatalf("got %v, want accepted", err)
+	}
+}
+
+func TestHL7EscapesDelimiters(t *testing.T) {
+	session := testHL7Session()
+	session.User.Name = `Ann|Smith^Jr & Co\`
+
+	pid := segmentsByName(buildADTMessage("A08", session, nil, nil, nil).Text)["PID"][0]
+	if !strings.Contains(pid, `|Ann\F\Smith\S\Jr \T\ Co\E\|`) {
+		t.Errorf("name is not escaped: %s", pid)
+	}
+}
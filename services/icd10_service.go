@@ -0,0 +1,280 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUnknownICD10Code = Validation("unknown_icd10_code", "unknown ICD-10 code")
+
+// ICD-10 chapters by range of three character categories
+var icd10Chapters = []struct {
+	start, end, chapter string
+}{
+	{"A00", "B99", "I"},
+	{"C00", "D48", "II"},
+	{"D50", "D89", "III"},
+	{"E00", "E90", "IV"},
+	{"F00", "F99", "V"},
+	{"G00", "G99", "VI"},
+	{"H00", "H59", "VII"},
+	{"H60", "H95", "VIII"},
+	{"I00", "I99", "IX"},
+	{"J00", "J99", "X"},
+	{"K00", "K93", "XI"},
+	{"L00", "L99", "XII"},
+	{"M00", "M99", "XIII"},
+	{"N00", "N99", "XIV"},
+	{"O00", "O99", "XV"},
+	{"P00", "P96", "XVI"},
+	{"Q00", "Q99", "XVII"},
+	{"R00", "R99", "XVIII"},
+	{"S00", "T98", "XIX"},
+	{"V01", "Y98", "XX"},
+	{"Z00", "Z99", "XXI"},
+	{"U00", "U85", "XXII"},
+}
+
+// ICD10Chapter returns the roman numeral of the chapter a code belongs to, or "" for invalid codes
+func ICD10Chapter(code string) string {
+	code = NormalizeICD10Code(code)
+	if len(code) < 3 {
+		return ""
+	}
+
+	category := code[:3]
+	for _, chapter := range icd10Chapters {
+		if category >= chapter.start && category <= chapter.end {
+			return chapter.chapter
+		}
+	}
+
+	return ""
+}
+
+// NormalizeICD10Code uppercases a code and adds the dot after the category, e.g. "j069" becomes "J06.9"
+func NormalizeICD10Code(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) > 3 && !strings.Contains(code, ".") {
+		code = code[:3] + "." + code[3:]
+	}
+	return code
+}
+
+// LoadICD10Catalog upserts the codes of a CSV file with a "code,title" header into the catalog
+func LoadICD10Catalog(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open ICD-10 catalog: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	if _, err := reader.Read(); err != nil {
+		return 0, fmt.Errorf("failed to read ICD-10 catalog header: %w", err)
+	}
+
+	var codes []models.ICD10Code
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read ICD-10 catalog: %w", err)
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		code := NormalizeICD10Code(record[0])
+		codes = append(codes, models.ICD10Code{
+			Code:    code,
+			Title:   strings.TrimSpace(record[1]),
+			Chapter: ICD10Chapter(code),
+		})
+	}
+
+	if len(codes) == 0 {
+		return 0, nil
+	}
+
+	err = config.DB.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(&codes, 500).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to save ICD-10 catalog: %w", err)
+	}
+
+	return len(codes), nil
+}
+
+// SeedICD10Catalog loads the bundled catalog (or ICD10_CSV_PATH) when the catalog is still empty
+func SeedICD10Catalog() {
+	var count int64
+	config.DB.Model(&models.ICD10Code{}).Count(&count)
+	if count > 0 {
+		return
+	}
+
+	loaded, err := LoadICD10Catalog(settings.Catalogs.ICD10CSVPath)
+	if err != nil {
+		slog.Error("Error loading ICD-10 catalog", "path", settings.Catalogs.ICD10CSVPath, "error", err)
+		return
+	}
+
+	slog.Info("Loaded ICD-10 catalog", "count", loaded, "path", settings.Catalogs.ICD10CSVPath)
+}
+
+// SearchICD10 finds codes starting with the query or titles containing it, code matches first
+func SearchICD10(query string, limit int) ([]models.ICD10Code, error) {
+	query = strings.TrimSpace(query)
+	pattern := "%" + query + "%"
+	codePattern := strings.ToUpper(query) + "%"
+
+	var codes []models.ICD10Code
+	err := config.DB.
+		Where("code LIKE ? OR title ILIKE ?", codePattern, pattern).
+		Order(clause.Expr{SQL: "CASE WHEN code LIKE ? THEN 0 ELSE 1 END", Vars: []interface{}{codePattern}}).
+		Order("code ASC").
+		Limit(limit).
+		Find(&codes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ICD-10 codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// splitICD10Codes normalizes the codes and separates the ones missing from the catalog, duplicates are dropped
+func splitICD10Codes(db *gorm.DB, codes []string) ([]string, []string, error) {
+	var normalized []string
+	seen := map[string]bool{}
+	for _, code := range codes {
+		code = NormalizeICD10Code(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			normalized = append(normalized, code)
+		}
+	}
+
+	if len(normalized) == 0 {
+		return nil, nil, nil
+	}
+
+	var existing []models.ICD10Code
+	if err := db.Where("code IN ?", normalized).Find(&existing).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to check ICD-10 codes: %w", err)
+	}
+
+	found := map[string]bool{}
+	for _, code := range existing {
+		found[code.Code] = true
+	}
+
+	var known, unknown []string
+	for _, code := range normalized {
+		if found[code] {
+			known = append(known, code)
+		} else {
+			unknown = append(unknown, code)
+		}
+	}
+
+	return known, unknown, nil
+}
+
+// replaceCodedDiagnoses replaces the codes of a session from one source, ranked in the given order
+func replaceCodedDiagnoses(db *gorm.DB, sessionID uuid.UUID, source string, codes []string) error {
+	err := db.Where("session_id = ? AND source = ?", sessionID, source).Delete(&models.CodedDiagnosis{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to remove coded diagnoses: %w", err)
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var diagnoses []models.CodedDiagnosis
+	for i, code := range codes {
+		diagnoses = append(diagnoses, models.CodedDiagnosis{
+			SessionID: sessionID,
+			Source:    source,
+			Code:      code,
+			Rank:      i + 1,
+			CreatedAt: now,
+		})
+	}
+
+	if err := db.Create(&diagnoses).Error; err != nil {
+		return fmt.Errorf("failed to save coded diagnoses: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateICD10Codes fails with ErrUnknownICD10Code when a code is missing from the catalog
+func ValidateICD10Codes(codes []string) error {
+	_, unknown, err := splitICD10Codes(config.DB, codes)
+	if err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownICD10Code, strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// SaveAIDiagnosisCodes stores the codes the LLM proposed, codes missing from the catalog are ignored
+func SaveAIDiagnosisCodes(ctx context.Context, sessionID uuid.UUID, codes []string) error {
+	known, unknown, err := splitICD10Codes(config.DB.WithContext(ctx), codes)
+	if err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		slog.WarnContext(ctx, "Ignoring unknown ICD-10 codes from LLM", "session_id", sessionID, "codes", unknown)
+	}
+
+	return replaceCodedDiagnoses(config.DB.WithContext(ctx), sessionID, models.DiagnosisSourceAI, known)
+}
+
+// SaveDoctorDiagnosisCodes stores the codes chosen by the doctor, primary diagnosis first
+func SaveDoctorDiagnosisCodes(sessionID uuid.UUID, codes []string) error {
+	return saveDoctorDiagnosisCodes(config.DB, sessionID, codes)
+}
+
+func saveDoctorDiagnosisCodes(db *gorm.DB, sessionID uuid.UUID, codes []string) error {
+	known, unknown, err := splitICD10Codes(db, codes)
+	if err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownICD10Code, strings.Join(unknown, ", "))
+	}
+
+	return replaceCodedDiagnoses(db, sessionID, models.DiagnosisSourceDoctor, known)
+}
+
+func GetCodedDiagnoses(sessionID uuid.UUID) []models.CodedDiagnosis {
+	var diagnoses []models.CodedDiagnosis
+	err := config.DB.Preload("ICD10").Where("session_id = ?", sessionID).Order("source ASC, rank ASC").Find(&diagnoses).Error
+	if err != nil {
+		return nil
+	}
+	return diagnoses
+}
@@ -0,0 +1,48 @@
+package services
+
+import "testing"
+
+func TestNormalizeICD10Code(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"J06.9", "J06.9"},
+		{"j069", "J06.9"},
+		{" i10 ", "I10"},
+		{"e11.65", "E11.65"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeICD10Code(tt.code); got != tt.want {
+			t.Errorf("NormalizeICD10Code(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestICD10Chapter(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"A09", "I"},
+		{"b99", "I"},
+		{"D48.9", "II"},
+		{"D50", "III"},
+		{"J069", "X"},
+		{"S72.0", "XIX"},
+		{"T98", "XIX"},
+		{"U07.1", "XXII"},
+		{"Z00.0", "XXI"},
+		{"D49", ""},
+		{"J0", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ICD10Chapter(tt.code); got != tt.want {
+			t.Errorf("ICD10Chapter(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
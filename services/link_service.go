@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/utils"
+	"github.com/google/uuid"
+)
+
+// signedLink builds a link to the web app that carries a token for the action, e.g. /queue/:id/cancel?token=...
+func signedLink(resource string, action string, id uuid.UUID, expiresAt time.Time) string {
+	token := utils.SignToken(resource+"/"+action, id.String(), expiresAt)
+	return fmt.Sprintf("%s/%s/%s/%s?token=%s", settings.App.WebAppURL, resource, id, action, token)
+}
+
+// queueLinkExpiry is the end of the day of a queue entry, the patient can only come on that day
+func queueLinkExpiry(queue models.Queue) time.Time {
+	created := queue.CreatedAt
+	return time.Date(created.Year(), created.Month(), created.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+}
+
+// appointmentLinkExpiry is the end of the appointment, it cannot be cancelled or checked in afterwards
+func appointmentLinkExpiry(appointment models.Appointment) time.Time {
+	return storedLocalTime(appointment.EndsAt)
+}
+
+// storedLocalTime re-bases a timestamp read from the database on time.Local. Timestamps are stored without
+// time zone in local time and read back as that wall clock labelled UTC.
+func storedLocalTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
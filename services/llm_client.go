@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/metrics"
+	"github.com/BeeCodingAI/triana-api/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genai"
+)
+
+// newLLMClient creates a Gemini client with the configured API key
+func newLLMClient(ctx context.Context) (*genai.Client, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey:  settings.LLM.APIKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating Gemini client", "error", err)
+		return nil, llmError(fmt.Errorf("error creating LLM client: %w", err))
+	}
+	return client, nil
+}
+
+// llmModel is the Gemini model used for all calls
+func llmModel() string {
+	return settings.LLM.Model
+}
+
+// responseText returns the text of the first candidate of a response
+func responseText(res *genai.GenerateContentResponse) (string, error) {
+	if res != nil && len(res.Candidates) > 0 && res.Candidates[0].Content != nil &&
+		len(res.Candidates[0].Content.Parts) > 0 {
+		return res.Candidates[0].Content.Parts[0].Text, nil
+	}
+	return "", llmError(fmt.Errorf("no response from LLM"))
+}
+
+// llmError reports a failed Gemini call as an upstream error, the cause is logged but not returned to the client
+func llmError(err error) error {
+	return Upstream("llm_unavailable", "the assistant is not available, try again later", err)
+}
+
+// startLLMSpan starts the span of a Gemini call, the prompts are not recorded because they hold patient data
+func startLLMSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "llm."+operation,
+		attribute.String("gen_ai.system", "gemini"),
+		attribute.String("gen_ai.operation.name", operation),
+		attribute.String("gen_ai.request.model", llmModel()),
+	)
+}
+
+// observeLLMCall records the latency, failure and token usage of a Gemini call in the metrics
+// and the token usage on the span in ctx
+func observeLLMCall(ctx context.Context, operation string, started time.Time, res *genai.GenerateContentResponse, err error) {
+	metrics.ObserveLLMCall(operation, time.Since(started), err)
+	if res == nil || res.UsageMetadata == nil {
+		return
+	}
+
+	usage := res.UsageMetadata
+	metrics.AddLLMTokens(operation, "prompt", usage.PromptTokenCount)
+	metrics.AddLLMTokens(operation, "cached", usage.CachedContentTokenCount)
+	metrics.AddLLMTokens(operation, "output", usage.CandidatesTokenCount)
+	metrics.AddLLMTokens(operation, "thoughts", usage.ThoughtsTokenCount)
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", int(usage.PromptTokenCount)),
+		attribute.Int("gen_ai.usage.output_tokens", int(usage.CandidatesTokenCount)),
+	)
+}
+
+// generateJSON runs a single stateless completion whose output must follow the schema,
+// operation names the call in the metrics
+func generateJSON(ctx context.Context, operation string, systemPrompt string, prompt string, schema *genai.Schema) (text string, err error) {
+	ctx, span := startLLMSpan(ctx, operation)
+	defer func() { tracing.End(span, err) }()
+
+	client, err := newLLMClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var temperature float32 = 0
+	config := &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(systemPrompt, genai.RoleUser),
+		ResponseMIMEType:  "application/json",
+		Temperature:       &temperature,
+		MaxOutputTokens:   8192,
+		ResponseSchema:    schema,
+	}
+
+	started := time.Now()
+	res, err := client.Models.GenerateContent(ctx, llmModel(), genai.Text(prompt), config)
+	observeLLMCall(ctx, operation, started, res, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating content", "operation", operation, "error", err)
+		return "", llmError(fmt.Errorf("error generating content: %w", err))
+	}
+
+	return responseText(res)
+}
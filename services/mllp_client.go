@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// MLLP frames an HL7 message between a start block and an end block followed by a carriage return
+const (
+	mllpStartBlock = byte(0x0b)
+	mllpEndBlock   = byte(0x1c)
+	mllpTrailer    = byte(0x0d)
+)
+
+var ErrHL7Rejected = errors.New("HL7 message rejected")
+
+// HL7Sender delivers an HL7 v2 message and returns the acknowledgment of the receiver
+type HL7Sender interface {
+	Send(message string) (string, error)
+}
+
+// MLLPSender sends every message over a new TCP connection using the minimal lower layer protocol
+type MLLPSender struct {
+	Address string
+	Timeout time.Duration
+}
+
+func NewMLLPSender(address string, timeout time.Duration) *MLLPSender {
+	return &MLLPSender{Address: address, Timeout: timeout}
+}
+
+func (s *MLLPSender) Send(message string) (string, error) {
+	conn, err := net.DialTimeout("tcp", s.Address, s.Timeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to HL7 receiver: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(s.Timeout)); err != nil {
+		return "", fmt.Errorf("failed to set HL7 connection deadline: %w", err)
+	}
+
+	if err := writeMLLPFrame(conn, message); err != nil {
+		return "", err
+	}
+
+	ack, err := readMLLPFrame(bufio.NewReader(conn))
+	if err != nil {
+		return "", fmt.Errorf("failed to read HL7 acknowledgment: %w", err)
+	}
+
+	return ack, nil
+}
+
+func writeMLLPFrame(conn net.Conn, message string) error {
+	frame := make([]byte, 0, len(message)+3)
+	frame = append(frame, mllpStartBlock)
+	frame = append(frame, message...)
+	frame = append(frame, mllpEndBlock, mllpTrailer)
+
+	if _, err := conn.Write(frame); err != nil {
+		return fmt.Errorf("failed to send HL7 message: %w", err)
+	}
+	return nil
+}
+
+// readMLLPFrame reads one framed message, anything before the start block is skipped
+func readMLLPFrame(reader *bufio.Reader) (string, error) {
+	if _, err := reader.ReadBytes(mllpStartBlock); err != nil {
+		return "", err
+	}
+
+	var message []byte
+	for {
+		chunk, err := reader.ReadBytes(mllpEndBlock)
+		if err != nil {
+			return "", err
+		}
+		message = append(message, chunk[:len(chunk)-1]...)
+
+		next, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if next == mllpTrailer {
+			return string(message), nil
+		}
+		// an end block that is not followed by the trailer is part of the message
+		message = append(message, mllpEndBlock, next)
+	}
+}
+
+// checkHL7Ack verifies that the acknowledgment accepts the message with the control ID
+func checkHL7Ack(ack string, controlID string) error {
+	for _, segment := range strings.Split(strings.TrimSpace(ack), "\r") {
+		fields := strings.Split(strings.TrimSpace(segment), "|")
+		if fields[0] != "MSA" {
+			continue
+		}
+		if len(fields) < 3 {
+			return fmt.Errorf("%w: malformed MSA segment", ErrHL7Rejected)
+		}
+		if fields[2] != controlID {
+			return fmt.Errorf("%w: acknowledgment for message %s instead of %s", ErrHL7Rejected, fields[2], controlID)
+		}
+
+		switch fields[1] {
+		case "AA", "CA":
+			return nil
+		default:
+			text := ""
+			if len(fields) > 3 {
+				text = fields[3]
+			}
+			return fmt.Errorf("%w: %s %s", ErrHL7Rejected, fields[1], text)
+		}
+	}
+
+	return fmt.Errorf("%w: acknowledgment without MSA segment", ErrHL7Rejected)
+}
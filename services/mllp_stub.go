@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MLLPStub is a local HL7 receiver for tests and development, it stores every message and accepts it with an AA acknowledgment
+type MLLPStub struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	messages []string
+	wg       sync.WaitGroup
+
+	// AckCode is the acknowledgment code sent back, AA by default
+	AckCode string
+}
+
+// StartMLLPStub listens on the address, use "127.0.0.1:0" for a free port
+func StartMLLPStub(address string) (*MLLPStub, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	stub := &MLLPStub{listener: listener, AckCode: "AA"}
+	stub.wg.Add(1)
+	go stub.serve()
+	return stub, nil
+}
+
+func (s *MLLPStub) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Messages returns the messages received so far
+func (s *MLLPStub) Messages() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// Close stops listening and waits for the open connections
+func (s *MLLPStub) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *MLLPStub) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *MLLPStub) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		message, err := readMLLPFrame(reader)
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.messages = append(s.messages, message)
+		ackCode := s.AckCode
+		s.mutex.Unlock()
+
+		if err := writeMLLPFrame(conn, buildHL7Ack(message, ackCode)); err != nil {
+			return
+		}
+	}
+}
+
+// buildHL7Ack answers a message with its control ID
+func buildHL7Ack(message string, ackCode string) string {
+	msh := strings.Split(strings.SplitN(message, "\r", 2)[0], "|")
+	field := func(index int) string {
+		if index < len(msh) {
+			return msh[index]
+		}
+		return ""
+	}
+
+	// MSH-1 is the field separator itself, so MSH-n is at index n-1
+	return strings.Join([]string{
+		hl7Segment("MSH", map[int]string{
+			2: `^~\&`, 3: field(4), 4: field(5), 5: field(2), 6: field(3),
+			7: hl7Timestamp(time.Now()), 9: "ACK", 10: strings.ReplaceAll(uuid.NewString(), "-", "")[:20], 11: "P", 12: field(11),
+		}),
+		hl7Segment("MSA", map[int]string{1: ackCode, 2: field(9)}),
+	}, "\r")
+}
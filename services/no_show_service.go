@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+)
+
+// noShowCutoff returns the moment before which unattended queue entries and appointments count as no-shows.
+// Today only counts once the closing time plus the grace period has passed.
+func noShowCutoff(now time.Time) time.Time {
+	// the closing time is validated when the configuration is loaded
+	closing, _ := time.Parse("15:04", settings.Clinic.ClosingTime)
+	grace := settings.Clinic.NoShowGracePeriod
+
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	closingToday := todayStart.Add(time.Duration(closing.Hour())*time.Hour + time.Duration(closing.Minute())*time.Minute)
+
+	if now.Before(closingToday.Add(grace)) {
+		return todayStart
+	}
+	return todayStart.AddDate(0, 0, 1)
+}
+
+// MarkNoShows marks queue entries whose patient never checked in and appointments that were never checked in as NO_SHOW
+func MarkNoShows(ctx context.Context, now time.Time) (int64, error) {
+	cutoff := noShowCutoff(now)
+
+	result := config.DB.WithContext(ctx).Model(&models.Queue{}).
+		Where("status = ?", models.QueueStatusWaiting).
+		Where("arrived_at IS NULL").
+		Where("created_at < ?", cutoff).
+		Updates(map[string]interface{}{"status": models.QueueStatusNoShow, "updated_at": now})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark queue no-shows: %w", result.Error)
+	}
+	marked := result.RowsAffected
+
+	result = config.DB.WithContext(ctx).Model(&models.Appointment{}).
+		Where("status = ?", models.AppointmentStatusBooked).
+		Where("starts_at < ?", cutoff).
+		Updates(map[string]interface{}{"status": models.AppointmentStatusNoShow, "updated_at": now})
+	if result.Error != nil {
+		return marked, fmt.Errorf("failed to mark appointment no-shows: %w", result.Error)
+	}
+
+	return marked + result.RowsAffected, nil
+}
+
+// RunNoShowWorker marks no-shows periodically until the context is cancelled
+func RunNoShowWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			marked, err := MarkNoShows(ctx, now)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error marking no-shows", "error", err)
+			} else if marked > 0 {
+				slog.InfoContext(ctx, "Marked queue entries and appointments as no-show", "count", marked)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"html"
+	"log/slog"
+	"strings"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// NotifyDoctor emails a short message to the doctor, failures are only logged
+func NotifyDoctor(ctx context.Context, doctor models.Doctor, title string, message string) {
+	ctx, span := tracing.Start(ctx, "notify.doctor", attribute.String("notification.title", title))
+	defer span.End()
+
+	htmlString := readEmailTemplate("emails/doctor_notification_mail.html")
+	htmlString = strings.ReplaceAll(htmlString, "{{title}}", html.EscapeString(title))
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_name}}", html.EscapeString(doctor.Name))
+	htmlString = strings.ReplaceAll(htmlString, "{{message}}", html.EscapeString(message))
+
+	email := schemas.Email{
+		To:      doctor.Email,
+		Subject: title,
+		Body:    message,
+		From:    "triana@ai.com",
+		HTML:    htmlString,
+	}
+
+	if _, err := sendEmail(ctx, "doctor_notification", email, settings.Email.Token); err != nil {
+		slog.ErrorContext(ctx, "Error sending doctor notification", "doctor_id", doctor.ID, "error", err)
+	}
+}
@@ -1,38 +1,56 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"math/rand"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/BeeCodingAI/triana-api/config"
 	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
 	"github.com/BeeCodingAI/triana-api/schemas"
 )
 
+// OTPMailer delivers the one-time password to the patient
+type OTPMailer interface {
+	SendOTP(ctx context.Context, to string, otp string) error
+}
+
+type emailOTPMailer struct {
+	token string
+}
+
+// NewEmailOTPMailer sends the one-time password through the email relay
+func NewEmailOTPMailer(token string) OTPMailer {
+	return &emailOTPMailer{token: token}
+}
+
+func (m *emailOTPMailer) SendOTP(ctx context.Context, to string, otp string) error {
+	_, err := sendOTPEmail(ctx, to, otp, m.token)
+	return err
+}
+
 func generateOTP() string {
 	// Generate a random 6-digit OTP
 	otp := fmt.Sprintf("%06d", rand.Intn(1000000))
 	return otp
 }
 
-func ValidateOTP(input schemas.OTPInput) (*models.Session, error) {
+func (s *UserService) ValidateOTP(ctx context.Context, input schemas.OTPInput) (*models.Session, error) {
 	// get the user from the input
-	var user models.User
-	err := config.DB.Where("email = ?", input.Email).First(&user).Error
+	user, err := s.users.FindByEmail(ctx, input.Email)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
 	}
 
 	// check if OTP sent to the user is valid
 	if user.OTP != input.OTP {
-		return nil, fmt.Errorf("invalid OTP")
+		return nil, ErrInvalidOTP
 	}
 
 	// create a new session for the user with the data from the input
@@ -47,14 +65,14 @@ func ValidateOTP(input schemas.OTPInput) (*models.Session, error) {
 	}
 
 	// save the session to the database
-	err = config.DB.Create(&newSession).Error
+	err = s.sessions.Create(ctx, &newSession)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// update user's OTP to nil after successful validation
 	user.OTP = ""
-	err = config.DB.Save(&user).Error
+	err = s.users.Save(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user OTP: %w", err)
 	}
@@ -62,7 +80,7 @@ func ValidateOTP(input schemas.OTPInput) (*models.Session, error) {
 	return &newSession, nil
 }
 
-func sendOTPEmail(to string, otp string, token string) (map[string]interface{}, error) {
+func sendOTPEmail(ctx context.Context, to string, otp string, token string) (map[string]interface{}, error) {
 
 	email := schemas.Email{
 		To:      to,
@@ -72,53 +90,11 @@ func sendOTPEmail(to string, otp string, token string) (map[string]interface{},
 		HTML:    injectOtpIntoHtml(otp),
 	}
 
-	// URL of the email service
-	url := "http://52.230.88.220:16250/send-email"
-
-	jsonData, err := json.Marshal(email)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal email request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("error from email service: %s", resp.Status)
-	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	return result, nil
+	return sendEmail(ctx, "otp", email, token)
 }
 
 func injectOtpIntoHtml(otpCode string) string {
-	// Path to the HTML file
-	htmlFilePath := "emails/otp_mail.html"
-
-	// Read the HTML file
-	htmlBytes, err := os.ReadFile(htmlFilePath)
-	if err != nil {
-		log.Fatalf("Failed to read HTML file: %v", err)
-	}
-
-	// Convert the file content to a string
-	htmlString := string(htmlBytes)
+	htmlString := readEmailTemplate("emails/otp_mail.html")
 
 	// Replace the placeholder with the OTP code
 	return strings.ReplaceAll(htmlString, "{{otp_code}}", otpCode)
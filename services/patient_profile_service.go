@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidProfile = Validation("invalid_profile", "invalid patient profile")
+
+// ICD-10 categories that are recorded as chronic conditions even when the doctor does not flag them
+var chronicICD10Categories = map[string]bool{
+	"E10": true, "E11": true, "E14": true, // diabetes mellitus
+	"E03": true, "E05": true, // hypo and hyperthyroidism
+	"E78": true,              // hyperlipidaemia
+	"I10": true, "I11": true, // hypertension
+	"I25": true, "I48": true, "I50": true, // chronic heart disease
+	"J44": true, "J45": true, // COPD and asthma
+	"N18": true,              // chronic kidney disease
+	"K21": true,              // gastro-oesophageal reflux disease
+	"M05": true, "M17": true, // rheumatoid arthritis and knee osteoarthritis
+	"G40": true,              // epilepsy
+	"B20": true, "B18": true, // HIV and chronic hepatitis
+}
+
+// IsChronicICD10Code tells whether a code belongs to a chronic condition
+func IsChronicICD10Code(code string) bool {
+	code = NormalizeICD10Code(code)
+	return len(code) >= 3 && chronicICD10Categories[code[:3]]
+}
+
+// GetPatientProfile returns the profile of a user, with defaults when the user never filled it in
+func GetPatientProfile(userID uuid.UUID) *models.PatientProfile {
+	var profile models.PatientProfile
+	err := config.DB.Where("user_id = ?", userID).First(&profile).Error
+	if err != nil {
+		profile = models.PatientProfile{
+			UserID:          userID,
+			PregnancyStatus: models.PregnancyStatusUnknown,
+			SmokingStatus:   models.SmokingStatusUnknown,
+		}
+	}
+
+	// the lists are kept per user so they exist even without a profile row
+	profile.Allergies = GetPatientAllergies(userID)
+	config.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&profile.ChronicConditions)
+	config.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&profile.Medications)
+
+	return &profile
+}
+
+// UpdatePatientProfile replaces the profile of a user, including the allergies, conditions and medications
+func UpdatePatientProfile(user *models.User, input schemas.PatientProfileInput) (*models.PatientProfile, error) {
+	if err := validateProfile(user, input); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var profile models.PatientProfile
+		err := tx.Where("user_id = ?", user.ID).First(&profile).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			profile = models.PatientProfile{UserID: user.ID, CreatedAt: now}
+		} else if err != nil {
+			return fmt.Errorf("failed to fetch patient profile: %w", err)
+		}
+
+		profile.PregnancyStatus = input.PregnancyStatus
+		profile.PregnancyWeeks = input.PregnancyWeeks
+		profile.SmokingStatus = input.SmokingStatus
+		profile.UpdatedAt = now
+		if err := tx.Omit("Allergies", "ChronicConditions", "Medications").Save(&profile).Error; err != nil {
+			return fmt.Errorf("failed to save patient profile: %w", err)
+		}
+
+		// the lists are replaced on every edit, the conditions a doctor recorded are kept
+		for _, model := range []interface{}{&models.PatientAllergy{}, &models.PatientMedication{}} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to update patient profile: %w", err)
+			}
+		}
+		err = tx.Where("user_id = ? AND source = ?", user.ID, models.ConditionSourcePatient).Delete(&models.PatientCondition{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update patient profile: %w", err)
+		}
+
+		// the profile sent back with the doctor's conditions does not copy them as the patient's
+		var recorded []models.PatientCondition
+		if err := tx.Where("user_id = ? AND source = ?", user.ID, models.ConditionSourceDoctor).Find(&recorded).Error; err != nil {
+			return fmt.Errorf("failed to fetch chronic conditions: %w", err)
+		}
+		known := map[string]bool{}
+		for _, condition := range recorded {
+			known[strings.ToLower(condition.Name)] = true
+			if condition.ICD10Code != "" {
+				known[condition.ICD10Code] = true
+			}
+		}
+
+		var allergies []models.PatientAllergy
+		for _, allergy := range input.Allergies {
+			allergies = append(allergies, models.PatientAllergy{
+				UserID:    user.ID,
+				Substance: strings.TrimSpace(allergy.Substance),
+				Reaction:  allergy.Reaction,
+				Severity:  allergy.Severity,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		var conditions []models.PatientCondition
+		for _, condition := range input.ChronicConditions {
+			name, code := strings.TrimSpace(condition.Name), NormalizeICD10Code(condition.ICD10Code)
+			if known[strings.ToLower(name)] || (code != "" && known[code]) {
+				continue
+			}
+			conditions = append(conditions, models.PatientCondition{
+				UserID:    user.ID,
+				Name:      name,
+				ICD10Code: code,
+				Source:    models.ConditionSourcePatient,
+				CreatedAt: now,
+			})
+		}
+		var medications []models.PatientMedication
+		for _, medication := range input.Medications {
+			medications = append(medications, models.PatientMedication{
+				UserID:    user.ID,
+				Name:      strings.TrimSpace(medication.Name),
+				Dose:      medication.Dose,
+				Frequency: medication.Frequency,
+				CreatedAt: now,
+			})
+		}
+
+		// gorm refuses to create an empty slice
+		if len(allergies) > 0 {
+			if err := tx.Create(&allergies).Error; err != nil {
+				return fmt.Errorf("failed to save allergies: %w", err)
+			}
+		}
+		if len(conditions) > 0 {
+			if err := tx.Create(&conditions).Error; err != nil {
+				return fmt.Errorf("failed to save chronic conditions: %w", err)
+			}
+		}
+		if len(medications) > 0 {
+			if err := tx.Create(&medications).Error; err != nil {
+				return fmt.Errorf("failed to save medications: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetPatientProfile(user.ID), nil
+}
+
+func validateProfile(user *models.User, input schemas.PatientProfileInput) error {
+	if input.PregnancyStatus == models.PregnancyStatusPregnant && strings.EqualFold(user.Gender, "male") {
+		return fmt.Errorf("%w: pregnancy status cannot be pregnant for a male patient", ErrInvalidProfile)
+	}
+	if input.PregnancyWeeks != nil && input.PregnancyStatus != models.PregnancyStatusPregnant {
+		return fmt.Errorf("%w: pregnancy weeks are only allowed when pregnant", ErrInvalidProfile)
+	}
+
+	seen := map[string]bool{}
+	for _, allergy := range input.Allergies {
+		key := strings.ToLower(strings.TrimSpace(allergy.Substance))
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate allergy %s", ErrInvalidProfile, allergy.Substance)
+		}
+		seen[key] = true
+	}
+
+	for _, condition := range input.ChronicConditions {
+		if condition.ICD10Code != "" && ICD10Chapter(condition.ICD10Code) == "" {
+			return fmt.Errorf("%w: invalid ICD-10 code %s", ErrInvalidProfile, condition.ICD10Code)
+		}
+	}
+
+	return nil
+}
+
+// recordChronicConditions adds the conditions a doctor diagnosed to the profile of the patient, known ones are skipped
+func recordChronicConditions(tx *gorm.DB, userID uuid.UUID, sessionID uuid.UUID, conditions []models.PatientCondition) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	var existing []models.PatientCondition
+	if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to fetch chronic conditions: %w", err)
+	}
+	known := map[string]bool{}
+	for _, condition := range existing {
+		known[strings.ToLower(condition.Name)] = true
+		if condition.ICD10Code != "" {
+			known[condition.ICD10Code] = true
+		}
+	}
+
+	now := time.Now()
+	var added []models.PatientCondition
+	for _, condition := range conditions {
+		if known[strings.ToLower(condition.Name)] || (condition.ICD10Code != "" && known[condition.ICD10Code]) {
+			continue
+		}
+		known[strings.ToLower(condition.Name)] = true
+		if condition.ICD10Code != "" {
+			known[condition.ICD10Code] = true
+		}
+
+		condition.UserID = userID
+		condition.Source = models.ConditionSourceDoctor
+		condition.SessionID = &sessionID
+		condition.CreatedAt = now
+		added = append(added, condition)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+	if err := tx.Create(&added).Error; err != nil {
+		return fmt.Errorf("failed to save chronic conditions: %w", err)
+	}
+	return nil
+}
+
+// RecordChronicDiagnosisCodes adds the chronic conditions among the ICD-10 codes a doctor diagnosed to the patient's profile
+func RecordChronicDiagnosisCodes(sessionID uuid.UUID, codes []string) error {
+	var chronic []string
+	for _, code := range codes {
+		if IsChronicICD10Code(code) {
+			chronic = append(chronic, NormalizeICD10Code(code))
+		}
+	}
+	if len(chronic) == 0 {
+		return nil
+	}
+
+	var session models.Session
+	if err := config.DB.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return fmt.Errorf("session not found: %w", err)
+	}
+
+	var catalog []models.ICD10Code
+	if err := config.DB.Where("code IN ?", chronic).Find(&catalog).Error; err != nil {
+		return fmt.Errorf("failed to fetch ICD-10 codes: %w", err)
+	}
+
+	var conditions []models.PatientCondition
+	for _, code := range catalog {
+		conditions = append(conditions, models.PatientCondition{Name: code.Title, ICD10Code: code.Code})
+	}
+
+	return recordChronicConditions(config.DB, session.UserID, session.ID, conditions)
+}
+
+// formatProfile renders the profile for the system prompt
+func formatProfile(profile *models.PatientProfile) string {
+	var allergies []string
+	for _, allergy := range profile.Allergies {
+		text := allergy.Substance
+		if allergy.Reaction != "" {
+			text += " (" + allergy.Reaction + ")"
+		}
+		allergies = append(allergies, text)
+	}
+
+	var conditions []string
+	for _, condition := range profile.ChronicConditions {
+		conditions = append(conditions, condition.Name)
+	}
+
+	var medications []string
+	for _, medication := range profile.Medications {
+		medications = append(medications, strings.TrimSpace(strings.Join([]string{medication.Name, medication.Dose, medication.Frequency}, " ")))
+	}
+
+	pregnancy := profile.PregnancyStatus
+	if profile.PregnancyWeeks != nil {
+		pregnancy = fmt.Sprintf("%s (%d weeks)", pregnancy, *profile.PregnancyWeeks)
+	}
+
+	return fmt.Sprintf(
+		"Allergies: %s\nChronic conditions: %s\nCurrent medications: %s\nPregnancy: %s\nSmoking: %s\n",
+		joinOrNone(allergies),
+		joinOrNone(conditions),
+		joinOrNone(medications),
+		pregnancy,
+		profile.SmokingStatus,
+	)
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none known"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownDrug     = Validation("unknown_drug", "unknown drug")
+	ErrAllergyConflict = Conflict("allergy_conflict", "the patient is allergic to a prescribed drug")
+)
+
+// CreatePrescriptions records the prescribed drugs of a session. When a drug matches an allergy of the
+// patient nothing is saved and the conflicts are returned with ErrAllergyConflict, unless the doctor overrides it
+func (s *SessionService) CreatePrescriptions(ctx context.Context, sessionID string, input schemas.PrescriptionInput) ([]models.Prescription, []schemas.AllergyConflict, error) {
+	session, err := s.GetSessionData(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doctorID, err := uuid.Parse(input.DoctorID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid doctor ID: %w", err)
+	}
+	if s.doctors.GetDoctorByID(ctx, input.DoctorID) == nil {
+		return nil, nil, ErrDoctorNotFound
+	}
+
+	// fetch the prescribed drugs from the catalog
+	drugIDs := make([]string, 0, len(input.Items))
+	for _, item := range input.Items {
+		drugID, err := uuid.Parse(item.DrugID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnknownDrug, item.DrugID)
+		}
+		drugIDs = append(drugIDs, drugID.String())
+	}
+	var drugs []models.Drug
+	if err := config.DB.WithContext(ctx).Where("id IN ?", drugIDs).Find(&drugs).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch drugs: %w", err)
+	}
+	drugsByID := map[string]models.Drug{}
+	for _, drug := range drugs {
+		drugsByID[drug.ID.String()] = drug
+	}
+	for _, id := range drugIDs {
+		if _, ok := drugsByID[id]; !ok {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnknownDrug, id)
+		}
+	}
+
+	conflicts := checkAllergies(GetPatientAllergies(session.UserID), drugs)
+	if len(conflicts) > 0 && !input.OverrideAllergy {
+		return nil, conflicts, ErrAllergyConflict
+	}
+
+	conflicting := map[string]bool{}
+	for _, conflict := range conflicts {
+		conflicting[conflict.DrugID] = true
+	}
+
+	now := time.Now()
+	prescriptions := make([]models.Prescription, 0, len(input.Items))
+	for i, item := range input.Items {
+		drug := drugsByID[drugIDs[i]]
+		prescriptions = append(prescriptions, models.Prescription{
+			SessionID:       session.ID,
+			DoctorID:        doctorID,
+			DrugID:          drug.ID,
+			Dose:            item.Dose,
+			Route:           item.Route,
+			Frequency:       item.Frequency,
+			DurationDays:    item.DurationDays,
+			Notes:           item.Notes,
+			AllergyOverride: conflicting[drugIDs[i]],
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+	}
+
+	if err := config.DB.WithContext(ctx).Omit("Drug", "Doctor").Create(&prescriptions).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to save prescriptions: %w", err)
+	}
+
+	for i := range prescriptions {
+		prescriptions[i].Drug = drugsByID[prescriptions[i].DrugID.String()]
+	}
+
+	return prescriptions, conflicts, nil
+}
+
+func GetPrescriptions(ctx context.Context, sessionID string) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := config.DB.WithContext(ctx).Preload("Drug").Where("session_id = ?", sessionID).Order("created_at ASC").Find(&prescriptions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prescriptions: %w", err)
+	}
+	return prescriptions, nil
+}
@@ -1,63 +1,67 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
-	"github.com/BeeCodingAI/triana-api/config"
 	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
 	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/utils"
 	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
 )
 
-func GenerateQueue(sessionID string, doctorID string) (*models.Queue, error) {
+type QueueService struct {
+	queues  repositories.QueueRepository
+	doctors *DoctorService
+}
+
+func NewQueueService(queues repositories.QueueRepository, doctors *DoctorService) *QueueService {
+	return &QueueService{queues: queues, doctors: doctors}
+}
+
+func (s *QueueService) GenerateQueue(ctx context.Context, sessionID string, doctorID string) (*models.Queue, error) {
+	return generateQueue(ctx, s.queues, sessionID, doctorID)
+}
+
+// generateQueue hands out the doctor's next number today through the given repository, which may be in a transaction
+func generateQueue(ctx context.Context, queues repositories.QueueRepository, sessionID string, doctorID string) (*models.Queue, error) {
 	var queue models.Queue
 
 	// parse the sessionID and doctorID to UUID
 	sessionUUID, err := uuid.Parse(sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid session ID: %w", err)
+		return nil, ErrSessionNotFound
 	}
 	queue.SessionID = sessionUUID
 
 	doctorUUID, err := uuid.Parse(doctorID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid doctor ID: %w", err)
+		return nil, Validation("invalid_doctor_id", "invalid doctor ID")
 	}
 	queue.DoctorID = doctorUUID
 
 	// get start of the current day
 	todayStart := time.Now().Truncate(24 * time.Hour)
 
-	// find the latest queue entry today universally
-	var latestQueue models.Queue
-	err = config.DB.
-		Where("doctor_id = ?", doctorID).
-		Where("created_at >= ?", todayStart).
-		Order("number DESC").
-		First(&latestQueue).Error
-
+	// continue from the latest queue number today, the first patient gets 1
+	latestNumber, err := queues.LatestNumber(ctx, doctorUUID, todayStart)
 	if err != nil {
-		// no queue entries found today, start from 1
-		queue.Number = 1
-	} else {
-		// increment the latest queue number by 1
-		queue.Number = latestQueue.Number + 1
+		return nil, fmt.Errorf("failed to get the latest queue number: %w", err)
 	}
+	queue.Number = latestNumber + 1
 
 	// set the created and updated time
 	now := time.Now()
+	queue.Status = models.QueueStatusWaiting
 	queue.CreatedAt = now
 	queue.UpdatedAt = now
 
 	// insert the queue entry into the database
-	err = config.DB.Create(&queue).Error
+	err = queues.Create(ctx, &queue)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create queue entry: %w", err)
 	}
@@ -65,110 +69,413 @@ func GenerateQueue(sessionID string, doctorID string) (*models.Queue, error) {
 	return &queue, nil
 }
 
-func GetCurrentQueue(doctorID uuid.UUID) (*models.Queue, error) {
+// GetCurrentQueue returns the patient the doctor calls next, patients who haven't checked in yet are skipped
+// and keep their number until they arrive
+func (s *QueueService) GetCurrentQueue(ctx context.Context, doctorID uuid.UUID) (*models.Queue, error) {
 	todayStart := time.Now().Truncate(24 * time.Hour)
 
-	var queue models.Queue
-	err := config.DB.
-		Joins("JOIN sessions ON sessions.id = queues.session_id").
-		Where("queues.doctor_id = ?", doctorID).
-		Where("queues.created_at >= ?", todayStart).
-		Where("sessions.doctor_diagnosis = ''").
-		Order("queues.number ASC").
-		Preload("Session"). // optional: preload session if you need it
-		First(&queue).Error
-
+	queue, err := s.queues.FindNext(ctx, doctorID, todayStart)
 	if err != nil {
 		return nil, fmt.Errorf("no queue found for today: %w", err)
 	}
 
-	return &queue, nil
+	return queue, nil
 }
 
-func GetTotalAppointments(doctorID uuid.UUID) int {
-	var count int64
-	config.DB.Model(&models.Queue{}).Where("doctor_id = ?", doctorID).Count(&count)
-	return int(count)
+// GetQueueWithDoctor returns the queue entry with the doctor the patient is sent to
+func (s *QueueService) GetQueueWithDoctor(ctx context.Context, queueID uuid.UUID) (*models.Queue, error) {
+	return s.queues.FindByID(ctx, queueID)
 }
 
-func GetDailyAppointments(doctorID uuid.UUID) int {
-	var count int64
-	today := time.Now().Truncate(24 * time.Hour)
-	config.DB.Model(&models.Queue{}).Where("doctor_id = ? AND created_at >= ?", doctorID, today).Count(&count)
-	return int(count)
+func (s *QueueService) GetTotalAppointments(ctx context.Context, doctorID uuid.UUID) int {
+	count, _ := s.queues.Count(ctx, doctorID, time.Time{})
+	return count
 }
 
-func SendQueueEmail(to string, queue int, currentQueue int, token string, doctor models.Doctor) (map[string]interface{}, error) {
+func (s *QueueService) GetDailyAppointments(ctx context.Context, doctorID uuid.UUID) int {
+	count, _ := s.queues.Count(ctx, doctorID, time.Now().Truncate(24*time.Hour))
+	return count
+}
+
+// SendQueueEmail sends the queue number to the patient, queue.Doctor must be preloaded
+func SendQueueEmail(ctx context.Context, to string, queue models.Queue, currentQueue int, token string, attachments []schemas.EmailAttachment) (map[string]interface{}, error) {
 	email := schemas.Email{
-		To:      to,
-		Subject: "Queue Notification",
-		Body:    "This is your queue number",
-		From:    "triana@ai.com",
-		HTML:    injectQueueIntoHTML(queue, currentQueue, doctor),
+		To:          to,
+		Subject:     "Queue Notification",
+		Body:        "This is your queue number",
+		From:        "triana@ai.com",
+		HTML:        injectQueueIntoHTML(queue, currentQueue),
+		Attachments: attachments,
 	}
 
-	// URL of the email service
-	url := "http://52.230.88.220:16250/send-email"
+	return sendEmail(ctx, "queue", email, token)
+}
+
+func injectQueueIntoHTML(queue models.Queue, currentQueue int) string {
+	htmlString := readEmailTemplate("emails/queue_mail.html")
+
+	htmlString = strings.ReplaceAll(htmlString, "{{queue_number}}", fmt.Sprintf("%d", queue.Number))
+	htmlString = strings.ReplaceAll(htmlString, "{{current_queue_number}}", fmt.Sprintf("%d", currentQueue))
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_name}}", queue.Doctor.Name)
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_specialty}}", queue.Doctor.Specialty)
+	htmlString = strings.ReplaceAll(htmlString, "{{room_number}}", queue.Doctor.Roomno)
+	htmlString = strings.ReplaceAll(htmlString, "{{check_in_qr_url}}", fmt.Sprintf(
+		"%s/queue/entry/%s/qr?token=%s", settings.App.APIBaseURL, queue.ID, utils.SignToken("queue/check-in", queue.ID.String(), queueLinkExpiry(queue)),
+	))
+	htmlString = strings.ReplaceAll(htmlString, "{{cancel_url}}", signedLink("queue", "cancel", queue.ID, queueLinkExpiry(queue)))
+	htmlString = strings.ReplaceAll(htmlString, "{{reschedule_url}}", signedLink("queue", "reschedule", queue.ID, queueLinkExpiry(queue)))
 
-	jsonData, err := json.Marshal(email)
+	return htmlString
+}
+
+func (s *QueueService) GetQueueBySessionID(ctx context.Context, sessionID uuid.UUID) *models.Queue {
+	queue, err := s.queues.FindLatestBySession(ctx, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal email request: %w", err)
+		return nil
 	}
+	return queue
+}
+
+// WaitingQueueLengths counts today's waiting patients not diagnosed yet per doctor ID, doctors without patients report 0
+func (s *QueueService) WaitingQueueLengths(ctx context.Context) (map[string]int, error) {
+	return s.queues.WaitingLengths(ctx, time.Now().Truncate(24*time.Hour))
+}
+
+var ErrQueueNotWaiting = Conflict("queue_not_waiting", "queue entry is no longer waiting")
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+// getQueueWithPatient fetches a queue entry with its doctor and the patient of the session
+func (s *QueueService) getQueueWithPatient(ctx context.Context, queueID uuid.UUID) (*models.Queue, error) {
+	queue, err := s.queues.FindWithPatient(ctx, queueID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("queue not found: %w", err)
 	}
+	return queue, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
+func (s *QueueService) GetQueueByID(ctx context.Context, queueID uuid.UUID) *models.Queue {
+	queue, err := s.getQueueWithPatient(ctx, queueID)
+	if err != nil {
+		return nil
+	}
+	return queue
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// CancelQueue cancels a waiting queue entry and lets the doctor know
+func (s *QueueService) CancelQueue(ctx context.Context, queueID uuid.UUID) (*models.Queue, error) {
+	queue, err := s.getQueueWithPatient(ctx, queueID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("error from email service: %s", resp.Status)
+	if queue.Status != models.QueueStatusWaiting {
+		return nil, ErrQueueNotWaiting
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	err = s.queues.Transaction(ctx, func(queues repositories.QueueRepository) error {
+		if err := lockWaitingQueue(ctx, queues, queue); err != nil {
+			return err
+		}
+		return setQueueStatus(ctx, queues, queue, models.QueueStatusCancelled)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	NotifyDoctor(ctx, queue.Doctor, "Appointment Cancelled", fmt.Sprintf(
+		"%s (queue number %d) has cancelled their appointment today.",
+		queue.Session.User.Name, queue.Number,
+	))
+
+	return queue, nil
 }
 
-func injectQueueIntoHTML(queue int, currentQueue int, doctor models.Doctor) string {
-	// Path to the HTML file
-	htmlFilePath := "emails/queue_mail.html"
+// RescheduleQueue moves a waiting queue entry to another doctor today or to a slot on a later date.
+// It returns either the new queue entry or the booked appointment.
+func (s *QueueService) RescheduleQueue(ctx context.Context, queueID uuid.UUID, input schemas.RescheduleInput) (*models.Queue, *models.Appointment, error) {
+	queue, err := s.getQueueWithPatient(ctx, queueID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if queue.Status != models.QueueStatusWaiting {
+		return nil, nil, ErrQueueNotWaiting
+	}
 
-	// Read the HTML file
-	htmlBytes, err := os.ReadFile(htmlFilePath)
+	doctorID := queue.DoctorID.String()
+	if input.DoctorID != "" {
+		doctorID = input.DoctorID
+	}
+
+	newDoctor := s.doctors.GetDoctorByID(ctx, doctorID)
+	if newDoctor == nil {
+		return nil, nil, ErrDoctorNotFound
+	}
+
+	date, future, err := parseRescheduleDate(input.Date)
 	if err != nil {
-		log.Fatalf("Failed to read HTML file: %v", err)
+		return nil, nil, err
+	}
+	if !future && doctorID == queue.DoctorID.String() {
+		return nil, nil, Conflict("same_doctor", "queue entry is already with this doctor today")
 	}
 
-	// Convert the file content to a string
-	htmlString := string(htmlBytes)
+	// the old entry is only cancelled together with the new entry or appointment
+	var newQueue *models.Queue
+	var appointment *models.Appointment
+	err = s.queues.Transaction(ctx, func(queues repositories.QueueRepository) error {
+		err := lockWaitingQueue(ctx, queues, queue)
+		if err != nil {
+			return err
+		}
+
+		if future {
+			appointment, err = BookFirstAvailableSlot(ctx, queue.SessionID.String(), doctorID, date)
+		} else {
+			newQueue, err = generateQueue(ctx, queues, queue.SessionID.String(), doctorID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return setQueueStatus(ctx, queues, queue, models.QueueStatusCancelled)
+	})
+	if err != nil {
+		// the appointment is booked in its own transaction, it is released when the queue entry stays
+		if appointment != nil {
+			releaseAppointment(ctx, appointment)
+		}
+		return nil, nil, err
+	}
+	if newQueue != nil {
+		newQueue.Doctor = *newDoctor
+	}
 
-	htmlString = strings.ReplaceAll(htmlString, "{{queue_number}}", fmt.Sprintf("%d", queue))
-	htmlString = strings.ReplaceAll(htmlString, "{{current_queue_number}}", fmt.Sprintf("%d", currentQueue))
-	htmlString = strings.ReplaceAll(htmlString, "{{doctor_name}}", doctor.Name)
-	htmlString = strings.ReplaceAll(htmlString, "{{doctor_specialty}}", doctor.Specialty)
-	htmlString = strings.ReplaceAll(htmlString, "{{room_number}}", doctor.Roomno)
+	// notify the doctors whose queue changed
+	NotifyDoctor(ctx, queue.Doctor, "Appointment Rescheduled", fmt.Sprintf(
+		"%s (queue number %d) has moved their appointment and left your queue today.",
+		queue.Session.User.Name, queue.Number,
+	))
+	if newQueue != nil {
+		NotifyDoctor(ctx, *newDoctor, "New Patient in Queue", fmt.Sprintf(
+			"%s has moved their appointment to you, their queue number is %d.",
+			queue.Session.User.Name, newQueue.Number,
+		))
+	} else {
+		NotifyDoctor(ctx, *newDoctor, "New Appointment Booked", fmt.Sprintf(
+			"%s has moved their appointment to you on %s.",
+			queue.Session.User.Name, appointment.StartsAt.Format("2006-01-02 15:04"),
+		))
+	}
 
-	return htmlString
+	return newQueue, appointment, nil
 }
 
-func GetQueueBySessionID(sessionID uuid.UUID) *models.Queue {
-	var queue models.Queue
-	err := config.DB.Where("session_id = ?", sessionID).Order("created_at DESC").First(&queue).Error
+// parseRescheduleDate parses the YYYY-MM-DD date of a reschedule, an empty date or today keeps the patient in today's
+// queues and a later date books an appointment
+func parseRescheduleDate(value string) (date time.Time, future bool, err error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	date, err = time.ParseInLocation("2006-01-02", value, time.Local)
 	if err != nil {
+		return time.Time{}, false, Validation("invalid_date", fmt.Sprintf("invalid date %q, use YYYY-MM-DD", value))
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if date.Before(today) {
+		return time.Time{}, false, Validation("date_in_past", fmt.Sprintf("date %s is in the past", value))
+	}
+
+	return date, date.After(today), nil
+}
+
+// lockWaitingQueue locks the queue entry in the transaction of queues and reloads its columns, a concurrent
+// cancel, check-in or transfer of the entry waits for the transaction instead of being overwritten
+func lockWaitingQueue(ctx context.Context, queues repositories.QueueRepository, queue *models.Queue) error {
+	locked, err := queues.FindForUpdate(ctx, queue.ID)
+	if err != nil {
+		return fmt.Errorf("queue not found: %w", err)
+	}
+	if locked.Status != models.QueueStatusWaiting {
+		return ErrQueueNotWaiting
+	}
+
+	queue.Status = locked.Status
+	queue.ArrivedAt = locked.ArrivedAt
+	queue.Priority = locked.Priority
+	queue.UpdatedAt = locked.UpdatedAt
+	return nil
+}
+
+// setQueueStatus saves the new status through the given repository, which may be in a transaction
+func setQueueStatus(ctx context.Context, queues repositories.QueueRepository, queue *models.Queue, status string) error {
+	queue.Status = status
+	queue.UpdatedAt = time.Now()
+
+	if err := queues.Save(ctx, queue); err != nil {
+		return fmt.Errorf("failed to update queue entry: %w", err)
+	}
+
+	return nil
+}
+
+// CheckInQueue marks the patient of a queue entry as arrived at the clinic, checking in twice is allowed
+func (s *QueueService) CheckInQueue(ctx context.Context, queueID uuid.UUID) (*models.Queue, error) {
+	queue, err := s.getQueueWithPatient(ctx, queueID)
+	if err != nil {
+		return nil, err
+	}
+
+	if queue.Status != models.QueueStatusWaiting {
+		return nil, ErrQueueNotWaiting
+	}
+
+	now := time.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if queue.CreatedAt.Before(todayStart) {
+		return nil, Conflict("queue_not_today", fmt.Sprintf("queue entry is from %s", queue.CreatedAt.Format("2006-01-02")))
+	}
+
+	if queue.ArrivedAt == nil {
+		err := s.queues.Transaction(ctx, func(queues repositories.QueueRepository) error {
+			if err := lockWaitingQueue(ctx, queues, queue); err != nil {
+				return err
+			}
+			if queue.ArrivedAt != nil {
+				return nil // checked in by a second scan in the meantime
+			}
+			return markQueueArrived(ctx, queues, queue)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return queue, nil
+}
+
+// markQueueArrived saves the check-in through the given repository, which may be in a transaction
+func markQueueArrived(ctx context.Context, queues repositories.QueueRepository, queue *models.Queue) error {
+	now := time.Now()
+	queue.ArrivedAt = &now
+	queue.UpdatedAt = now
+
+	if err := queues.Save(ctx, queue); err != nil {
+		return fmt.Errorf("failed to check in queue entry: %w", err)
+	}
+
+	return nil
+}
+
+// GenerateCheckInQRCode renders the check-in link of a queue entry as a PNG QR code for the clinic kiosk
+func GenerateCheckInQRCode(queue models.Queue) ([]byte, error) {
+	png, err := qrcode.Encode(signedLink("queue", "check-in", queue.ID, queueLinkExpiry(queue)), qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	return png, nil
+}
+
+// TransferQueue moves a waiting patient to another doctor's queue on the doctor's request.
+// The original assignment and the reason are recorded for routing analytics.
+func (s *QueueService) TransferQueue(ctx context.Context, queueID uuid.UUID, input schemas.TransferQueueInput) (*models.Queue, error) {
+	queue, err := s.getQueueWithPatient(ctx, queueID)
+	if err != nil {
+		return nil, err
+	}
+
+	if queue.Status != models.QueueStatusWaiting {
+		return nil, ErrQueueNotWaiting
+	}
+
+	if input.DoctorID == queue.DoctorID.String() {
+		return nil, Conflict("same_doctor", "queue entry is already with this doctor")
+	}
+
+	newDoctor := s.doctors.GetDoctorByID(ctx, input.DoctorID)
+	if newDoctor == nil {
+		return nil, ErrDoctorNotFound
+	}
+
+	// the new entry, the old status and the transfer record are saved together or not at all
+	var newQueue *models.Queue
+	err = s.queues.Transaction(ctx, func(queues repositories.QueueRepository) error {
+		if err := lockWaitingQueue(ctx, queues, queue); err != nil {
+			return err
+		}
+
+		newQueue, err = generateQueue(ctx, queues, queue.SessionID.String(), input.DoctorID)
+		if err != nil {
+			return err
+		}
+
+		// the patient keeps their check-in and optionally skips the line of the new doctor
+		newQueue.ArrivedAt = queue.ArrivedAt
+		newQueue.Priority = input.Priority
+		if err := queues.Save(ctx, newQueue); err != nil {
+			return fmt.Errorf("failed to update queue entry: %w", err)
+		}
+
+		if err := setQueueStatus(ctx, queues, queue, models.QueueStatusTransferred); err != nil {
+			return err
+		}
+
+		transfer := models.QueueTransfer{
+			SessionID:      queue.SessionID,
+			FromQueueID:    queue.ID,
+			ToQueueID:      newQueue.ID,
+			FromDoctorID:   queue.DoctorID,
+			ToDoctorID:     newQueue.DoctorID,
+			OriginalNumber: queue.Number,
+			NewNumber:      newQueue.Number,
+			Priority:       input.Priority,
+			Reason:         input.Reason,
+			CreatedAt:      time.Now(),
+		}
+		if err := queues.CreateTransfer(ctx, &transfer); err != nil {
+			return fmt.Errorf("failed to record transfer: %w", err)
+		}
 		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return &queue
+	newQueue.Doctor = *newDoctor
+
+	NotifyDoctor(ctx, *newDoctor, "Patient Transferred to You", fmt.Sprintf(
+		"%s transferred %s to your queue with number %d. Reason: %s",
+		queue.Doctor.Name, queue.Session.User.Name, newQueue.Number, input.Reason,
+	))
+
+	return newQueue, nil
+}
+
+func (s *QueueService) GetQueueTransfers(ctx context.Context, sessionID uuid.UUID) []models.QueueTransfer {
+	transfers, err := s.queues.FindTransfers(ctx, sessionID)
+	if err != nil {
+		return nil
+	}
+	return transfers
+}
+
+// SendQueueTransferEmail tells the patient about their new doctor and room, queue.Doctor must be preloaded
+func SendQueueTransferEmail(ctx context.Context, to string, queue models.Queue, previousDoctor models.Doctor, token string) (map[string]interface{}, error) {
+	htmlString := readEmailTemplate("emails/queue_transfer_mail.html")
+	htmlString = strings.ReplaceAll(htmlString, "{{previous_doctor_name}}", previousDoctor.Name)
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_name}}", queue.Doctor.Name)
+	htmlString = strings.ReplaceAll(htmlString, "{{doctor_specialty}}", queue.Doctor.Specialty)
+	htmlString = strings.ReplaceAll(htmlString, "{{room_number}}", queue.Doctor.Roomno)
+	htmlString = strings.ReplaceAll(htmlString, "{{queue_number}}", fmt.Sprintf("%d", queue.Number))
+
+	email := schemas.Email{
+		To:      to,
+		Subject: "Your Appointment Has Been Transferred",
+		Body:    "Your appointment has been transferred to another doctor",
+		From:    "triana@ai.com",
+		HTML:    htmlString,
+	}
+
+	return sendEmail(ctx, "queue_transfer", email, token)
 }
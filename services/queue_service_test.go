@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+)
+
+func newTestQueueService() (*QueueService, repositories.Repositories, models.Doctor) {
+	doctor := models.Doctor{ID: uuid.NewString(), Name: "Dr. Sari", Specialty: "General Practitioner", Roomno: "101"}
+	repos := repositories.NewMemoryRepositories(doctor)
+	return NewQueueService(repos.Queues, NewDoctorService(repos.Doctors)), repos, doctor
+}
+
+// queueTestSession creates a session for a queue entry
+func queueTestSession(t *testing.T, repos repositories.Repositories) string {
+	t.Helper()
+
+	session := &models.Session{UserID: uuid.New(), CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := repos.Sessions.Create(t.Context(), session); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return session.ID.String()
+}
+
+func TestGenerateQueueNumbersPerDoctor(t *testing.T) {
+	service, repos, doctor := newTestQueueService()
+	otherDoctor := uuid.NewString()
+
+	for want := 1; want <= 3; want++ {
+		queue, err := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+		if err != nil {
+			t.Fatalf("GenerateQueue: %v", err)
+		}
+		if queue.Number != want || queue.Status != models.QueueStatusWaiting {
+			t.Errorf("queue = number %d status %s, want number %d WAITING", queue.Number, queue.Status, want)
+		}
+	}
+
+	// every doctor starts at 1
+	queue, err := service.GenerateQueue(t.Context(), queueTestSession(t, repos), otherDoctor)
+	if err != nil {
+		t.Fatalf("GenerateQueue: %v", err)
+	}
+	if queue.Number != 1 {
+		t.Errorf("other doctor's number = %d, want 1", queue.Number)
+	}
+
+	if _, err := service.GenerateQueue(t.Context(), "not-a-uuid", doctor.ID); err == nil {
+		t.Error("GenerateQueue accepted an invalid session ID")
+	}
+}
+
+func TestGetCurrentQueueCallsCheckedInPriorityFirst(t *testing.T) {
+	service, repos, doctor := newTestQueueService()
+	doctorID := uuid.MustParse(doctor.ID)
+	arrived := time.Now()
+
+	first, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	second, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	third, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+
+	// nobody has checked in yet
+	if _, err := service.GetCurrentQueue(t.Context(), doctorID); err == nil {
+		t.Fatal("GetCurrentQueue returned a patient who hasn't checked in")
+	}
+
+	// the memory store replaces entries with the same ID, like a check-in updates them
+	second.ArrivedAt = &arrived
+	third.ArrivedAt = &arrived
+	repos.Queues.Create(t.Context(), second)
+	repos.Queues.Create(t.Context(), third)
+
+	current, err := service.GetCurrentQueue(t.Context(), doctorID)
+	if err != nil {
+		t.Fatalf("GetCurrentQueue: %v", err)
+	}
+	if current.ID != second.ID {
+		t.Errorf("current = number %d, want number %d since number %d hasn't arrived", current.Number, second.Number, first.Number)
+	}
+
+	third.Priority = true
+	repos.Queues.Create(t.Context(), third)
+	if current, _ := service.GetCurrentQueue(t.Context(), doctorID); current == nil || current.ID != third.ID {
+		t.Errorf("current = %+v, want the priority entry", current)
+	}
+
+	// diagnosed patients are done
+	if err := NewSessionService(repos.Sessions, repos.Messages, repos.Queues, NewDoctorService(repos.Doctors)).DoctorDiagnose(t.Context(), third.SessionID.String(), "Migraine"); err != nil {
+		t.Fatalf("DoctorDiagnose: %v", err)
+	}
+	if current, _ := service.GetCurrentQueue(t.Context(), doctorID); current == nil || current.ID != second.ID {
+		t.Errorf("current = %+v, want the next entry after the diagnosed one", current)
+	}
+}
+
+func TestQueueCountsAndDoctor(t *testing.T) {
+	service, repos, doctor := newTestQueueService()
+	doctorID := uuid.MustParse(doctor.ID)
+
+	queue, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+
+	// an entry from an earlier day only counts for all time
+	yesterday := &models.Queue{DoctorID: doctorID, SessionID: uuid.New(), Number: 1, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	repos.Queues.Create(t.Context(), yesterday)
+
+	if total, daily := service.GetTotalAppointments(t.Context(), doctorID), service.GetDailyAppointments(t.Context(), doctorID); total != 3 || daily != 2 {
+		t.Errorf("appointments = %d total, %d daily, want 3 and 2", total, daily)
+	}
+
+	withDoctor, err := service.GetQueueWithDoctor(t.Context(), queue.ID)
+	if err != nil {
+		t.Fatalf("GetQueueWithDoctor: %v", err)
+	}
+	if withDoctor.Doctor.Roomno != "101" {
+		t.Errorf("doctor = %+v, want the queue's doctor", withDoctor.Doctor)
+	}
+}
+
+// stubEmailRelay points the doctor notifications at a local relay, the email templates are read from the repo root
+func stubEmailRelay(t *testing.T) {
+	t.Helper()
+
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status": "sent"}`))
+	}))
+	t.Cleanup(relay.Close)
+
+	previous := settings
+	settings = config.Defaults()
+	settings.Email.ServiceURL = relay.URL
+	t.Cleanup(func() { settings = previous })
+	t.Chdir("..")
+}
+
+func TestCheckInAndCancelQueue(t *testing.T) {
+	stubEmailRelay(t)
+	service, repos, doctor := newTestQueueService()
+
+	queue, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	if _, err := service.CheckInQueue(t.Context(), queue.ID); err != nil {
+		t.Fatalf("CheckInQueue: %v", err)
+	}
+	stored := service.GetQueueByID(t.Context(), queue.ID)
+	if stored == nil || stored.ArrivedAt == nil || stored.Doctor.Roomno != "101" {
+		t.Fatalf("queue after check-in = %+v, want it arrived with its doctor", stored)
+	}
+
+	if _, err := service.CancelQueue(t.Context(), queue.ID); err != nil {
+		t.Fatalf("CancelQueue: %v", err)
+	}
+	if stored := service.GetQueueByID(t.Context(), queue.ID); stored.Status != models.QueueStatusCancelled {
+		t.Errorf("status = %s, want CANCELLED", stored.Status)
+	}
+	if _, err := service.CancelQueue(t.Context(), queue.ID); !errors.Is(err, ErrQueueNotWaiting) {
+		t.Errorf("cancelling twice = %v, want ErrQueueNotWaiting", err)
+	}
+	if _, err := service.CheckInQueue(t.Context(), queue.ID); !errors.Is(err, ErrQueueNotWaiting) {
+		t.Errorf("checking in a cancelled entry = %v, want ErrQueueNotWaiting", err)
+	}
+	if service.GetQueueByID(t.Context(), uuid.New()) != nil {
+		t.Error("GetQueueByID found an unknown entry")
+	}
+}
+
+func TestTransferQueue(t *testing.T) {
+	stubEmailRelay(t)
+	from := models.Doctor{ID: uuid.NewString(), Name: "Dr. Sari", Specialty: "General Practitioner"}
+	to := models.Doctor{ID: uuid.NewString(), Name: "Dr. Budi", Specialty: "Neurologist"}
+	repos := repositories.NewMemoryRepositories(from, to)
+	service := NewQueueService(repos.Queues, NewDoctorService(repos.Doctors))
+
+	// the new doctor already has a patient waiting
+	service.GenerateQueue(t.Context(), queueTestSession(t, repos), to.ID)
+
+	queue, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), from.ID)
+	service.CheckInQueue(t.Context(), queue.ID)
+
+	if _, err := service.TransferQueue(t.Context(), queue.ID, schemas.TransferQueueInput{DoctorID: from.ID, Reason: "stay"}); err == nil {
+		t.Error("TransferQueue moved the patient to the same doctor")
+	}
+
+	moved, err := service.TransferQueue(t.Context(), queue.ID, schemas.TransferQueueInput{DoctorID: to.ID, Reason: "migraine", Priority: true})
+	if err != nil {
+		t.Fatalf("TransferQueue: %v", err)
+	}
+	if moved.Number != 2 || !moved.Priority || moved.ArrivedAt == nil || moved.Doctor.Name != "Dr. Budi" {
+		t.Errorf("new entry = %+v, want number 2 with priority, the check-in and Dr. Budi", moved)
+	}
+	if stored := service.GetQueueByID(t.Context(), moved.ID); stored == nil || !stored.Priority || stored.ArrivedAt == nil {
+		t.Errorf("stored new entry = %+v, want the priority and the check-in saved", stored)
+	}
+	if stored := service.GetQueueByID(t.Context(), queue.ID); stored.Status != models.QueueStatusTransferred {
+		t.Errorf("old status = %s, want TRANSFERRED", stored.Status)
+	}
+	if latest := service.GetQueueBySessionID(t.Context(), queue.SessionID); latest == nil || latest.ID != moved.ID {
+		t.Errorf("GetQueueBySessionID = %+v, want the new entry", latest)
+	}
+
+	transfers := service.GetQueueTransfers(t.Context(), queue.SessionID)
+	if len(transfers) != 1 {
+		t.Fatalf("got %d transfers, want 1", len(transfers))
+	}
+	transfer := transfers[0]
+	if transfer.FromDoctor.Name != "Dr. Sari" || transfer.ToDoctor.Name != "Dr. Budi" ||
+		transfer.OriginalNumber != 1 || transfer.NewNumber != 2 || transfer.Reason != "migraine" {
+		t.Errorf("transfer = %+v, want Dr. Sari number 1 to Dr. Budi number 2 for migraine", transfer)
+	}
+
+	if _, err := service.TransferQueue(t.Context(), queue.ID, schemas.TransferQueueInput{DoctorID: to.ID}); !errors.Is(err, ErrQueueNotWaiting) {
+		t.Errorf("transferring twice = %v, want ErrQueueNotWaiting", err)
+	}
+}
+
+func TestWaitingQueueLengths(t *testing.T) {
+	service, repos, doctor := newTestQueueService()
+	doctorID := uuid.MustParse(doctor.ID)
+
+	service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	diagnosed, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	cancelled, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), doctor.ID)
+	repos.Queues.Create(t.Context(), &models.Queue{DoctorID: doctorID, SessionID: uuid.New(), Number: 1, Status: models.QueueStatusWaiting, CreatedAt: time.Now().Add(-48 * time.Hour)})
+
+	NewSessionService(repos.Sessions, repos.Messages, repos.Queues, NewDoctorService(repos.Doctors)).DoctorDiagnose(t.Context(), diagnosed.SessionID.String(), "Migraine")
+	cancelled.Status = models.QueueStatusCancelled
+	repos.Queues.Save(t.Context(), cancelled)
+
+	lengths, err := service.WaitingQueueLengths(t.Context())
+	if err != nil {
+		t.Fatalf("WaitingQueueLengths: %v", err)
+	}
+	if len(lengths) != 1 || lengths[doctor.ID] != 1 {
+		t.Errorf("lengths = %v, want 1 waiting for %s", lengths, doctor.ID)
+	}
+}
+
+// failingTransfers fails to record transfers, inside transactions too
+type failingTransfers struct {
+	repositories.QueueRepository
+}
+
+func (r failingTransfers) CreateTransfer(ctx context.Context, transfer *models.QueueTransfer) error {
+	return errors.New("connection reset")
+}
+
+func (r failingTransfers) Transaction(ctx context.Context, fn func(queues repositories.QueueRepository) error) error {
+	return r.QueueRepository.Transaction(ctx, func(queues repositories.QueueRepository) error {
+		return fn(failingTransfers{queues})
+	})
+}
+
+func TestTransferQueueRollsBackWhenTheTransferIsNotRecorded(t *testing.T) {
+	from := models.Doctor{ID: uuid.NewString(), Name: "Dr. Sari"}
+	to := models.Doctor{ID: uuid.NewString(), Name: "Dr. Budi"}
+	repos := repositories.NewMemoryRepositories(from, to)
+	service := NewQueueService(failingTransfers{repos.Queues}, NewDoctorService(repos.Doctors))
+
+	queue, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), from.ID)
+	if _, err := service.TransferQueue(t.Context(), queue.ID, schemas.TransferQueueInput{DoctorID: to.ID, Reason: "migraine"}); err == nil {
+		t.Fatal("TransferQueue succeeded without recording the transfer")
+	}
+
+	if stored := service.GetQueueByID(t.Context(), queue.ID); stored.Status != models.QueueStatusWaiting {
+		t.Errorf("old status = %s, want it still WAITING", stored.Status)
+	}
+	if latest := service.GetQueueBySessionID(t.Context(), queue.SessionID); latest == nil || latest.ID != queue.ID {
+		t.Errorf("GetQueueBySessionID = %+v, want no new entry", latest)
+	}
+}
+
+func TestRescheduleQueueToday(t *testing.T) {
+	stubEmailRelay(t)
+	from := models.Doctor{ID: uuid.NewString(), Name: "Dr. Sari"}
+	to := models.Doctor{ID: uuid.NewString(), Name: "Dr. Budi"}
+	repos := repositories.NewMemoryRepositories(from, to)
+	service := NewQueueService(repos.Queues, NewDoctorService(repos.Doctors))
+
+	queue, _ := service.GenerateQueue(t.Context(), queueTestSession(t, repos), from.ID)
+
+	for _, date := range []string{"2000-01-01", time.Now().AddDate(0, 0, -1).Format("2006-01-02"), "next monday"} {
+		var serviceErr *Error
+		_, _, err := service.RescheduleQueue(t.Context(), queue.ID, schemas.RescheduleInput{DoctorID: to.ID, Date: date})
+		if !errors.As(err, &serviceErr) || serviceErr.Kind != KindValidation {
+			t.Errorf("RescheduleQueue(%q) = %v, want a validation error", date, err)
+		}
+	}
+	if _, _, err := service.RescheduleQueue(t.Context(), queue.ID, schemas.RescheduleInput{}); err == nil {
+		t.Error("RescheduleQueue moved the patient to the same doctor today")
+	}
+
+	moved, appointment, err := service.RescheduleQueue(t.Context(), queue.ID, schemas.RescheduleInput{DoctorID: to.ID, Date: time.Now().Format("2006-01-02")})
+	if err != nil {
+		t.Fatalf("RescheduleQueue: %v", err)
+	}
+	if appointment != nil || moved == nil || moved.Doctor.Name != "Dr. Budi" || moved.Number != 1 {
+		t.Fatalf("RescheduleQueue = %+v, %+v, want number 1 with Dr. Budi", moved, appointment)
+	}
+	if stored := service.GetQueueByID(t.Context(), queue.ID); stored.Status != models.QueueStatusCancelled {
+		t.Errorf("old status = %s, want CANCELLED", stored.Status)
+	}
+	if _, _, err := service.RescheduleQueue(t.Context(), queue.ID, schemas.RescheduleInput{DoctorID: to.ID}); !errors.Is(err, ErrQueueNotWaiting) {
+		t.Errorf("rescheduling twice = %v, want ErrQueueNotWaiting", err)
+	}
+}
@@ -3,21 +3,32 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"strings"
 	"time"
 
-	"github.com/BeeCodingAI/triana-api/config"
 	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
 	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/tracing"
 	"github.com/BeeCodingAI/triana-api/utils"
 	"github.com/google/uuid"
 	"google.golang.org/genai"
-	"gorm.io/gorm"
 )
 
+type SessionService struct {
+	sessions repositories.SessionRepository
+	messages repositories.MessageRepository
+	queues   repositories.QueueRepository
+	doctors  *DoctorService
+}
+
+func NewSessionService(sessions repositories.SessionRepository, messages repositories.MessageRepository, queues repositories.QueueRepository, doctors *DoctorService) *SessionService {
+	return &SessionService{sessions: sessions, messages: messages, queues: queues, doctors: doctors}
+}
+
 func convertMessageToGenaiContent(message models.Message) *genai.Content {
 	// Determine the role of the message
 	var role genai.Role
@@ -34,17 +45,14 @@ func convertMessageToGenaiContent(message models.Message) *genai.Content {
 	return content
 }
 
-func GetLLMResponse(newMessage string, session *models.Session) (string, error) {
-	// initialize the Gemini client with your API key and backend
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  os.Getenv("GEMINI_API_KEY"),
-		Backend: genai.BackendGeminiAPI,
-	})
+func (s *SessionService) GetLLMResponse(ctx context.Context, newMessage string, session *models.Session) (reply string, err error) {
+	ctx, span := startLLMSpan(ctx, "chat")
+	defer func() { tracing.End(span, err) }()
 
+	// initialize the Gemini client with your API key and backend
+	client, err := newLLMClient(ctx)
 	if err != nil {
-		log.Printf("Error creating Gemini client: %v\n", err)
-		return "", fmt.Errorf("error creating LLM client: %w", err)
+		return "", err
 	}
 
 	// the chat history is stored as a one-to-many relationship in the database
@@ -54,8 +62,8 @@ func GetLLMResponse(newMessage string, session *models.Session) (string, error)
 	var genaiHistory []*genai.Content
 
 	// build the system prompt using the session data
-	systemPromptText := buildSystemPrompt(session)
-	log.Printf("System Prompt: %s\n", systemPromptText)
+	systemPromptText := s.buildSystemPrompt(ctx, session)
+	slog.DebugContext(ctx, "System prompt", "session_id", session.ID, "system_prompt", systemPromptText)
 
 	var temperature float32 = 0.8
 	var TopP float32 = 0.95
@@ -68,10 +76,12 @@ func GetLLMResponse(newMessage string, session *models.Session) (string, error)
 		ResponseSchema: &genai.Schema{
 			Type: genai.TypeObject,
 			Properties: map[string]*genai.Schema{
-				"next_action":  {Type: genai.TypeString, Enum: []string{"CONTINUE_CHAT", "APPOINTMENT"}},
-				"reply":        {Type: genai.TypeString},
-				"doctor_id":    {Type: genai.TypeString},
-				"prediagnosis": {Type: genai.TypeString},
+				"next_action":      {Type: genai.TypeString, Enum: []string{"CONTINUE_CHAT", "APPOINTMENT"}},
+				"reply":            {Type: genai.TypeString},
+				"doctor_id":        {Type: genai.TypeString},
+				"prediagnosis":     {Type: genai.TypeString},
+				"appointment_date": {Type: genai.TypeString},
+				"icd10_codes":      {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeString}},
 			},
 			Required: []string{"next_action", "reply", "doctor_id", "prediagnosis"},
 		},
@@ -85,33 +95,28 @@ func GetLLMResponse(newMessage string, session *models.Session) (string, error)
 		}
 	}
 
-	chat, err := client.Chats.Create(ctx, os.Getenv("GEMINI_MODEL"), config, genaiHistory)
+	chat, err := client.Chats.Create(ctx, llmModel(), config, genaiHistory)
 	if err != nil {
-		log.Printf("Error creating chat: %v\n", err)
-		return "", fmt.Errorf("error creating chat: %w", err)
+		slog.ErrorContext(ctx, "Error creating chat", "error", err)
+		return "", llmError(fmt.Errorf("error creating chat: %w", err))
 	}
 
+	started := time.Now()
 	res, err := chat.SendMessage(ctx, genai.Part{Text: newMessage})
+	observeLLMCall(ctx, "chat", started, res, err)
 	if err != nil {
-		log.Printf("Error sending message: %v\n", err)
-		return "", fmt.Errorf("error sending message: %w", err)
+		slog.ErrorContext(ctx, "Error sending message", "error", err)
+		return "", llmError(fmt.Errorf("error sending message: %w", err))
 	}
 
 	// get the response from the LLM
-	if res != nil && len(res.Candidates) > 0 && res.Candidates[0].Content != nil &&
-		len(res.Candidates[0].Content.Parts) > 0 {
-		text := res.Candidates[0].Content.Parts[0].Text
-		return text, nil
-	}
-
-	return "", fmt.Errorf("no response from LLM")
+	return responseText(res)
 }
 
-func UpdateChatHistory(sessionId string, newMessage string, LLMResponse string) error {
+func (s *SessionService) UpdateChatHistory(ctx context.Context, sessionId string, newMessage string, LLMResponse string) error {
 
 	// get the session from the database
-	var session models.Session
-	err := config.DB.Where("id = ?", sessionId).First(&session).Error
+	session, err := s.findSession(ctx, sessionId)
 	if err != nil {
 		return fmt.Errorf("error fetching session: %v", err)
 	}
@@ -122,21 +127,21 @@ func UpdateChatHistory(sessionId string, newMessage string, LLMResponse string)
 	newLLMResponse := models.Message{Role: "triana", Content: LLMResponse, SessionID: session.ID, CreatedAt: now.Add(time.Millisecond), UpdatedAt: now.Add(time.Millisecond)}
 
 	// save the new messages to the database
-	if err := config.DB.Create(&newUserMessage).Error; err != nil {
+	if err := s.messages.Create(ctx, &newUserMessage); err != nil {
 		return fmt.Errorf("error saving user message: %v", err)
 	}
 
-	if err := config.DB.Create(&newLLMResponse).Error; err != nil {
+	if err := s.messages.Create(ctx, &newLLMResponse); err != nil {
 		return fmt.Errorf("error saving LLM response: %v", err)
 	}
 
 	return nil
 }
 
-func buildSystemPrompt(session *models.Session) string {
+func (s *SessionService) buildSystemPrompt(ctx context.Context, session *models.Session) string {
 	// Build the system prompt using the user's data
 	userDataText := fmt.Sprintf(
-		"\nHere's the user's data: \n\nName:%s\nAge:%s\nGender:%s\nNationality:%s\nWeight: %d\nHeight: %d\nHeartrate: %d\nBodytemp: %f\n",
+		"\nHere's the user's data: \n\nName:%s\nAge:%s\nGender:%s\nNationality:%s\nWeight: %f\nHeight: %f\nHeartrate: %f\nBodytemp: %f\n",
 		session.User.Name,
 		utils.DateToAgeString(session.User.DOB),
 		session.User.Gender,
@@ -146,18 +151,20 @@ func buildSystemPrompt(session *models.Session) string {
 		session.Heartrate,
 		session.Bodytemp,
 	)
+	userDataText += formatProfile(GetPatientProfile(session.User.ID))
 
-	doctors := GetAllDoctors()
+	doctors := s.doctors.GetAllDoctors(ctx)
+	schedules := s.doctors.GetSchedulesByDoctor(ctx)
 
 	// Convert the doctors to a string representation
 	var doctorList []string
 	for _, doctor := range doctors {
-		doctorList = append(doctorList, fmt.Sprintf("- [%s] %s (%s)\n", doctor.ID, doctor.Name, doctor.Specialty))
+		doctorList = append(doctorList, fmt.Sprintf("- [%s] %s (%s) Schedule: %s\n", doctor.ID, doctor.Name, doctor.Specialty, formatSchedules(schedules[doctor.ID])))
 	}
-	doctorListText := fmt.Sprintf("\nHere are the doctors available [ID] Name (Specialty):\n%s", strings.Join(doctorList, ""))
+	doctorListText := fmt.Sprintf("\nHere are the doctors available [ID] Name (Specialty) Schedule:\n%s", strings.Join(doctorList, ""))
 
 	// Get history of sessions
-	var history []models.Session = GetHistory(session)
+	var history []models.Session = s.GetHistory(ctx, session)
 
 	// Convert history of sessions to a string representation
 	var historyList []string
@@ -193,7 +200,9 @@ Follow the conversation flow and output format strictly as described below. It i
 	\"next_action\": \"CONTINUE_CHAT\" or \"APPOINTMENT\",
 	\"reply\": \"Your text reply here\",
 	\"doctor_id\": \"selected doctor_id\" (only if next_action is APPOINTMENT),
-	\"prediagnosis\": \"Your pre-diagnosis based on the conversation\" (only if next_action is APPOINTMENT)
+	\"prediagnosis\": \"Your pre-diagnosis based on the conversation\" (only if next_action is APPOINTMENT),
+	\"appointment_date\": \"YYYY-MM-DD\" (only if next_action is APPOINTMENT and the patient wants to come on a later date),
+	\"icd10_codes\": [\"ICD-10 code\"] (only if next_action is APPOINTMENT)
 	}
 	
 -  Detailed explanation of each field:
@@ -208,6 +217,10 @@ Follow the conversation flow and output format strictly as described below. It i
 
 		prediagnosis: A string containing your pre-diagnosis based on the conversation. This *MUST be included if and only if next_action is \"APPOINTMENT\".  Be brief and provide a likely possible diagnosis.
 
+		icd10_codes: A list of up to 3 candidate ICD-10 codes (WHO ICD-10, e.g. \"J06.9\") that match the prediagnosis, the most likely first. This *MUST be included if and only if next_action is \"APPOINTMENT\".
+
+		appointment_date: A string with the date (YYYY-MM-DD) the patient wants to visit the doctor. Leave it empty when the patient comes today, they will get a queue number. Only fill it when the patient asks for a later date, the earliest free slot on that date will be booked. The date MUST be a day on which the selected doctor has a schedule.
+
 -  Example JSON Response (for CONTINUE_CHAT):
 
 	{
@@ -223,7 +236,8 @@ Follow the conversation flow and output format strictly as described below. It i
 	\"next_action\": \"APPOINTMENT\",
 	\"reply\": \"Based on your symptoms, I recommend you see Dr. Jane Doe (Cardiologist). Your queue number has been sent to your email address.\",
 	\"doctor_id\": \"edd248b7-75d3-4af2-a954-183970124e9d\",
-	\"prediagnosis\": \"Possible arrhythmia\"
+	\"prediagnosis\": \"Possible arrhythmia\",
+	\"icd10_codes\": [\"I49.9\", \"I48.9\"]
 	}
 	
 1.   Conversation Flow:
@@ -250,6 +264,7 @@ Follow the conversation flow and output format strictly as described below. It i
 			-  Select a suitable doctor_id from the provided doctor list. If no suitable doctor is available based on the conversation, assign the patient to a General Practitioner.
 			-  Make the next_action \"APPOINTMENT\".
 			-  Don't assume their sickness based on the symptoms, ask their symptoms first.
+			-  If the patient cannot come today, ask which date suits them and fill appointment_date. Check the doctor's schedule first and suggest another day if the doctor does not practice on the requested day.
 
 2.   Important Notes:
 
@@ -265,54 +280,84 @@ Follow the conversation flow and output format strictly as described below. It i
 	return systemPromptText
 }
 
-func GetSessionData(sessionId string) (models.Session, error) {
-	// check if session_id exists in the database
-	var session models.Session
+func formatSchedules(schedules []models.DoctorSchedule) string {
+	if len(schedules) == 0 {
+		return "walk-in only"
+	}
 
-	err := config.DB.
-		Preload("User").
-		Preload("Messages", func(db *gorm.DB) *gorm.DB {
-			return db.Order("created_at ASC") // Order messages by created_at in descending order (for latest messages first)
-		}).
-		Where("id = ?", sessionId).First(&session).Error
+	var parts []string
+	for _, schedule := range schedules {
+		parts = append(parts, fmt.Sprintf("%s %s-%s", time.Weekday(schedule.Weekday).String()[:3], schedule.StartTime, schedule.EndTime))
+	}
+
+	return strings.Join(parts, ", ")
+}
 
+// findSession looks the session up by its ID as given in the URL
+func (s *SessionService) findSession(ctx context.Context, sessionId string) (*models.Session, error) {
+	id, err := uuid.Parse(sessionId)
 	if err != nil {
-		return models.Session{}, fmt.Errorf("session not found: %w", err)
+		return nil, repositories.ErrNotFound
 	}
+	return s.sessions.FindByID(ctx, id)
+}
 
-	return session, nil
+func (s *SessionService) GetSessionData(ctx context.Context, sessionId string) (models.Session, error) {
+	// check if session_id exists in the database
+	session, err := s.findSession(ctx, sessionId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return models.Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return models.Session{}, fmt.Errorf("failed to fetch session: %w", err)
+	}
+
+	return *session, nil
 }
 
-func GetHistory(session *models.Session) []models.Session {
-	var history []models.Session
-	err := config.DB.Where("user_id = ?", session.User.ID).Where("id != ?", session.ID).Order("created_at DESC").Find(&history).Error
+func (s *SessionService) GetHistory(ctx context.Context, session *models.Session) []models.Session {
+	history, err := s.sessions.FindHistory(ctx, session.User.ID, session.ID)
 	if err != nil {
-		log.Printf("Error fetching session history: %v\n", err)
+		slog.ErrorContext(ctx, "Error fetching session history", "session_id", session.ID, "error", err)
 		return []models.Session{} // Return an empty slice if there's an error
 	}
 
 	return history
 }
 
-func DoctorDiagnose(sessionId string, diagnosis string) error {
+func (s *SessionService) DoctorDiagnose(ctx context.Context, sessionId string, diagnosis string) error {
 	// Fetch the session from the database
-	var session models.Session
-	err := config.DB.Where("id = ?", sessionId).First(&session).Error
+	session, err := s.findSession(ctx, sessionId)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrSessionNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return fmt.Errorf("failed to fetch session: %w", err)
 	}
 
 	// Update the session with the doctor's diagnosis
 	session.DoctorDiagnosis = diagnosis
 	session.UpdatedAt = time.Now()
 
-	if err := config.DB.Save(&session).Error; err != nil {
+	if err := s.sessions.Save(ctx, session); err != nil {
 		return fmt.Errorf("failed to save diagnosis: %w", err)
 	}
 
 	return nil
 }
 
+// SavePrediagnosis stores the AI prediagnosis once the chat ends in an appointment
+func (s *SessionService) SavePrediagnosis(ctx context.Context, session *models.Session, prediagnosis string) error {
+	session.Prediagnosis = prediagnosis
+	session.UpdatedAt = time.Now()
+
+	if err := s.sessions.Save(ctx, session); err != nil {
+		return fmt.Errorf("failed to save prediagnosis: %w", err)
+	}
+
+	return nil
+}
+
 // RemoveMarkdownAndExtractJSON removes Markdown syntax and extracts the JSON content
 func ParseJSON(input string) (schemas.LLMResponse, error) {
 
@@ -326,9 +371,8 @@ func ParseJSON(input string) (schemas.LLMResponse, error) {
 	return responseJSON, nil
 }
 
-func GetSessionsByUserID(userID uuid.UUID) []models.Session {
-	var sessions []models.Session
-	err := config.DB.Where("user_id = ?", userID).Find(&sessions).Error
+func (s *SessionService) GetSessionsByUserID(ctx context.Context, userID uuid.UUID) []models.Session {
+	sessions, err := s.sessions.FindByUserID(ctx, userID)
 	if err != nil {
 		return nil
 	}
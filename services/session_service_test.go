@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
+	"github.com/google/uuid"
+)
+
+func newTestSessionService(t *testing.T) (*SessionService, repositories.Repositories, *models.User) {
+	t.Helper()
+
+	repos := repositories.NewMemoryRepositories()
+	user := &models.User{Name: "Mario Rossi", Email: "mario@example.com", Gender: "Male", DOB: "1990-05-17"}
+	if err := repos.Users.Create(t.Context(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return NewSessionService(repos.Sessions, repos.Messages, repos.Queues, NewDoctorService(repos.Doctors)), repos, user
+}
+
+func createTestSession(t *testing.T, repos repositories.Repositories, userID uuid.UUID, createdAt time.Time) *models.Session {
+	t.Helper()
+
+	session := &models.Session{UserID: userID, Weight: 70, CreatedAt: createdAt, UpdatedAt: createdAt}
+	if err := repos.Sessions.Create(t.Context(), session); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	return session
+}
+
+func TestUpdateChatHistoryStoresBothMessagesInOrder(t *testing.T) {
+	service, repos, user := newTestSessionService(t)
+	session := createTestSession(t, repos, user.ID, time.Now())
+
+	if err := service.UpdateChatHistory(t.Context(), session.ID.String(), "I have a headache", `{"reply":"Since when?"}`); err != nil {
+		t.Fatalf("UpdateChatHistory: %v", err)
+	}
+
+	stored, err := service.GetSessionData(t.Context(), session.ID.String())
+	if err != nil {
+		t.Fatalf("GetSessionData: %v", err)
+	}
+	if stored.User.ID != user.ID {
+		t.Errorf("session user = %s, want %s", stored.User.ID, user.ID)
+	}
+	if len(stored.Messages) != 2 || stored.Messages[0].Role != "user" || stored.Messages[1].Role != "triana" {
+		t.Fatalf("messages = %+v, want the user message followed by the reply", stored.Messages)
+	}
+}
+
+func TestUpdateChatHistoryUnknownSession(t *testing.T) {
+	service, _, _ := newTestSessionService(t)
+
+	if err := service.UpdateChatHistory(t.Context(), uuid.NewString(), "hello", "hi"); err == nil {
+		t.Error("UpdateChatHistory accepted an unknown session")
+	}
+	if _, err := service.GetSessionData(t.Context(), "not-a-uuid"); err == nil {
+		t.Error("GetSessionData accepted an invalid session ID")
+	}
+}
+
+func TestGetHistoryExcludesCurrentSessionNewestFirst(t *testing.T) {
+	service, repos, user := newTestSessionService(t)
+	now := time.Now()
+	oldest := createTestSession(t, repos, user.ID, now.Add(-48*time.Hour))
+	older := createTestSession(t, repos, user.ID, now.Add(-24*time.Hour))
+	current := createTestSession(t, repos, user.ID, now)
+	current.User = *user
+
+	history := service.GetHistory(t.Context(), current)
+	if len(history) != 2 || history[0].ID != older.ID || history[1].ID != oldest.ID {
+		t.Errorf("history = %+v, want the two earlier sessions newest first", history)
+	}
+
+	if sessions := service.GetSessionsByUserID(t.Context(), user.ID); len(sessions) != 3 {
+		t.Errorf("GetSessionsByUserID returned %d sessions, want 3", len(sessions))
+	}
+}
+
+func TestDoctorDiagnoseAndPrediagnosis(t *testing.T) {
+	service, repos, user := newTestSessionService(t)
+	session := createTestSession(t, repos, user.ID, time.Now())
+
+	if err := service.SavePrediagnosis(t.Context(), session, "Tension headache"); err != nil {
+		t.Fatalf("SavePrediagnosis: %v", err)
+	}
+	if err := service.DoctorDiagnose(t.Context(), session.ID.String(), "Migraine"); err != nil {
+		t.Fatalf("DoctorDiagnose: %v", err)
+	}
+
+	stored, err := service.GetSessionData(t.Context(), session.ID.String())
+	if err != nil {
+		t.Fatalf("GetSessionData: %v", err)
+	}
+	if stored.Prediagnosis != "Tension headache" || stored.DoctorDiagnosis != "Migraine" {
+		t.Errorf("prediagnosis = %q, diagnosis = %q", stored.Prediagnosis, stored.DoctorDiagnosis)
+	}
+
+	if err := service.DoctorDiagnose(t.Context(), uuid.NewString(), "Migraine"); err == nil {
+		t.Error("DoctorDiagnose accepted an unknown session")
+	}
+}
@@ -0,0 +1,11 @@
+package services
+
+import "github.com/BeeCodingAI/triana-api/config"
+
+// settings is the configuration the services read, main passes the loaded one to Configure
+var settings = config.Defaults()
+
+// Configure passes the configuration to the services, it is called once at startup
+func Configure(cfg *config.Config) {
+	settings = cfg
+}
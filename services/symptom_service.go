@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/BeeCodingAI/triana-api/config"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/google/uuid"
+	"google.golang.org/genai"
+	"gorm.io/gorm"
+)
+
+const symptomExtractionPrompt = `You extract symptoms from a conversation between a patient and a triage assistant. The conversation may be in Indonesian or English.
+
+Return every symptom the patient reported, in English, in the order of importance for the visit. For each symptom fill:
+- symptom: short clinical name, e.g. "fever", "sore throat"
+- onset: when or how it started, e.g. "3 days ago", "sudden"
+- duration: how long it lasts or has lasted, e.g. "constant", "5 days"
+- severity: "mild", "moderate", "severe" or "unknown"
+- location: body location, if any
+- modifiers: what makes it better or worse and associated findings
+
+Only use what the patient said. Use an empty string for anything that was not mentioned. Do not add symptoms the patient denied. Return an empty list when no symptom was reported.`
+
+var symptomSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"symptoms": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"symptom":   {Type: genai.TypeString},
+					"onset":     {Type: genai.TypeString},
+					"duration":  {Type: genai.TypeString},
+					"severity":  {Type: genai.TypeString, Enum: []string{"mild", "moderate", "severe", "unknown"}},
+					"location":  {Type: genai.TypeString},
+					"modifiers": {Type: genai.TypeString},
+				},
+				Required: []string{"symptom", "onset", "duration", "severity", "location", "modifiers"},
+			},
+		},
+	},
+	Required: []string{"symptoms"},
+}
+
+// ExtractSessionSymptoms asks the LLM for the structured symptoms of the chat transcript and replaces the stored ones
+func (s *SessionService) ExtractSessionSymptoms(ctx context.Context, sessionID string) ([]models.SessionSymptom, error) {
+	session, err := s.GetSessionData(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := buildTranscript(session.Messages)
+	if transcript == "" {
+		return []models.SessionSymptom{}, nil
+	}
+
+	text, err := generateJSON(ctx, "symptom_extraction", symptomExtractionPrompt, transcript, symptomSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	var extraction schemas.SymptomExtraction
+	if err := json.Unmarshal([]byte(text), &extraction); err != nil {
+		return nil, llmError(fmt.Errorf("failed to parse extracted symptoms: %w", err))
+	}
+
+	symptoms := buildSessionSymptoms(session.ID, extraction.Symptoms)
+	err = config.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", session.ID).Delete(&models.SessionSymptom{}).Error; err != nil {
+			return fmt.Errorf("failed to update symptoms: %w", err)
+		}
+		if len(symptoms) == 0 {
+			return nil
+		}
+		if err := tx.Create(&symptoms).Error; err != nil {
+			return fmt.Errorf("failed to save symptoms: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return symptoms, nil
+}
+
+// ExtractSessionSymptomsAsync runs the extraction in the background so the patient does not wait for it,
+// it stays in the trace of the request but is not cancelled when the request ends
+func (s *SessionService) ExtractSessionSymptomsAsync(ctx context.Context, sessionID string) {
+	ctx = context.WithoutCancel(ctx)
+	runInBackground(func() {
+		if _, err := s.ExtractSessionSymptoms(ctx, sessionID); err != nil {
+			slog.ErrorContext(ctx, "Error extracting symptoms", "session_id", sessionID, "error", err)
+		}
+	})
+}
+
+// buildTranscript renders the chat as plain text with the speaker of every message
+func buildTranscript(messages []models.Message) string {
+	var lines []string
+	for _, message := range messages {
+		speaker := "Patient"
+		// the assistant messages hold the plain text reply, not the JSON of the LLM
+		if message.Role == "triana" {
+			speaker = "Assistant"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", speaker, message.Content))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// buildSessionSymptoms converts the LLM output, dropping empty and duplicate symptoms
+func buildSessionSymptoms(sessionID uuid.UUID, extracted []schemas.ExtractedSymptom) []models.SessionSymptom {
+	now := time.Now()
+	seen := map[string]bool{}
+	symptoms := []models.SessionSymptom{}
+	for _, item := range extracted {
+		name := strings.TrimSpace(item.Symptom)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		severity := strings.ToLower(strings.TrimSpace(item.Severity))
+		switch severity {
+		case "mild", "moderate", "severe":
+		default:
+			severity = "unknown"
+		}
+
+		symptoms = append(symptoms, models.SessionSymptom{
+			SessionID: sessionID,
+			Symptom:   truncate(name, 100),
+			Onset:     truncate(strings.TrimSpace(item.Onset), 100),
+			Duration:  truncate(strings.TrimSpace(item.Duration), 100),
+			Severity:  severity,
+			Location:  truncate(strings.TrimSpace(item.Location), 100),
+			Modifiers: strings.TrimSpace(item.Modifiers),
+			Position:  len(symptoms),
+			CreatedAt: now,
+		})
+	}
+	return symptoms
+}
+
+func GetSessionSymptoms(sessionID uuid.UUID) []models.SessionSymptom {
+	var symptoms []models.SessionSymptom
+	config.DB.Where("session_id = ?", sessionID).Order("position ASC").Find(&symptoms)
+	return symptoms
+}
+
+// GetIntakeSummary returns the extracted symptoms of a session with a one line summary for the doctor
+func GetIntakeSummary(sessionID uuid.UUID) *schemas.IntakeSummary {
+	symptoms := GetSessionSymptoms(sessionID)
+	return &schemas.IntakeSummary{Summary: formatSymptoms(symptoms), Symptoms: symptoms}
+}
+
+// formatSymptoms renders symptoms as e.g. "fever (since 3 days ago, moderate); cough (worse at night)"
+func formatSymptoms(symptoms []models.SessionSymptom) string {
+	var parts []string
+	for _, symptom := range symptoms {
+		var details []string
+		if symptom.Location != "" {
+			details = append(details, symptom.Location)
+		}
+		if symptom.Onset != "" {
+			details = append(details, "since "+symptom.Onset)
+		}
+		if symptom.Duration != "" {
+			details = append(details, symptom.Duration)
+		}
+		if symptom.Severity != "" && symptom.Severity != "unknown" {
+			details = append(details, symptom.Severity)
+		}
+		if symptom.Modifiers != "" {
+			details = append(details, symptom.Modifiers)
+		}
+
+		if len(details) == 0 {
+			parts = append(parts, symptom.Symptom)
+		} else {
+			parts = append(parts, fmt.Sprintf("%s (%s)", symptom.Symptom, strings.Join(details, ", ")))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
@@ -1,28 +1,36 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
 	"time"
 
-	"github.com/BeeCodingAI/triana-api/config"
 	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/repositories"
 	"github.com/BeeCodingAI/triana-api/schemas"
 	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
-func RegisterUser(input schemas.RegisterUserInput) (*models.User, error) {
+type UserService struct {
+	users    repositories.UserRepository
+	sessions repositories.SessionRepository
+	mailer   OTPMailer
+}
+
+func NewUserService(users repositories.UserRepository, sessions repositories.SessionRepository, mailer OTPMailer) *UserService {
+	return &UserService{users: users, sessions: sessions, mailer: mailer}
+}
+
+func (s *UserService) RegisterUser(ctx context.Context, input schemas.RegisterUserInput) (*models.User, error) {
 	// Generate OTP
 	otp := generateOTP()
 
 	// Check if the user already exists in the database
-	var existingUser models.User
-	err := config.DB.Preload("Sessions").Where("email = ?", input.Email).First(&existingUser).Error
+	existingUser, err := s.users.FindByEmail(ctx, input.Email)
 
-	if errors.Is(err, gorm.ErrRecordNotFound) {
+	if errors.Is(err, repositories.ErrNotFound) {
 		// User does not exist, create a new user
 		newUser := models.User{
 			Name:        input.Name,
@@ -35,10 +43,10 @@ func RegisterUser(input schemas.RegisterUserInput) (*models.User, error) {
 			UpdatedAt:   time.Now(),
 		}
 
-		if err := config.DB.Create(&newUser).Error; err != nil {
+		if err := s.users.Create(ctx, &newUser); err != nil {
 			return nil, fmt.Errorf("failed to create user: %w", err)
 		}
-		existingUser = newUser
+		existingUser = &newUser
 
 	} else if err == nil {
 		// update existing user with new OTP
@@ -50,7 +58,7 @@ func RegisterUser(input schemas.RegisterUserInput) (*models.User, error) {
 
 		existingUser.UpdatedAt = time.Now()
 
-		if err := config.DB.Save(&existingUser).Error; err != nil {
+		if err := s.users.Save(ctx, existingUser); err != nil {
 			return nil, fmt.Errorf("failed to update user: %w", err)
 		}
 	} else {
@@ -59,20 +67,18 @@ func RegisterUser(input schemas.RegisterUserInput) (*models.User, error) {
 	}
 
 	// Send OTP email to the user
-	_, err = sendOTPEmail(existingUser.Email, otp, os.Getenv("EMAIL_OTP_TOKEN"))
-	if err != nil {
-		log.Printf("Error sending OTP email: %v\n", err)
+	if err := s.mailer.SendOTP(ctx, existingUser.Email, otp); err != nil {
+		slog.ErrorContext(ctx, "Error sending OTP email", "user_id", existingUser.ID, "error", err)
 	}
 
 	// registration success, return the user object
-	return &existingUser, nil
+	return existingUser, nil
 }
 
-func GetUserByID(userID uuid.UUID) *models.User {
-	var user models.User
-	err := config.DB.First(&user, "id = ?", userID).Error
+func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) *models.User {
+	user, err := s.users.FindByID(ctx, userID)
 	if err != nil {
 		return nil
 	}
-	return &user
+	return user
 }
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/BeeCodingAI/triana-api/repositories"
+	"github.com/BeeCodingAI/triana-api/schemas"
+)
+
+// fakeOTPMailer remembers the last one-time password instead of emailing it
+type fakeOTPMailer struct {
+	to  string
+	otp string
+}
+
+func (m *fakeOTPMailer) SendOTP(ctx context.Context, to string, otp string) error {
+	m.to, m.otp = to, otp
+	return nil
+}
+
+func newTestUserService() (*UserService, repositories.Repositories, *fakeOTPMailer) {
+	repos := repositories.NewMemoryRepositories()
+	mailer := &fakeOTPMailer{}
+	return NewUserService(repos.Users, repos.Sessions, mailer), repos, mailer
+}
+
+func testRegisterInput() schemas.RegisterUserInput {
+	return schemas.RegisterUserInput{
+		Name:        "Mario Rossi",
+		Email:       "mario@example.com",
+		Nationality: "Italian",
+		DOB:         "1990-05-17",
+		Gender:      "Male",
+	}
+}
+
+func TestRegisterUserCreatesUserAndSendsOTP(t *testing.T) {
+	service, _, mailer := newTestUserService()
+
+	user, err := service.RegisterUser(t.Context(), testRegisterInput())
+	if err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+
+	if mailer.to != "mario@example.com" || len(mailer.otp) != 6 {
+		t.Errorf("OTP sent to %q as %q, want a 6 digit code to mario@example.com", mailer.to, mailer.otp)
+	}
+	if user.OTP != mailer.otp {
+		t.Errorf("stored OTP = %q, want the one sent %q", user.OTP, mailer.otp)
+	}
+	if service.GetUserByID(t.Context(), user.ID) == nil {
+		t.Error("GetUserByID did not find the registered user")
+	}
+}
+
+func TestRegisterUserUpdatesExistingUser(t *testing.T) {
+	service, _, _ := newTestUserService()
+
+	first, err := service.RegisterUser(t.Context(), testRegisterInput())
+	if err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+
+	input := testRegisterInput()
+	input.Name = "Mario R."
+	second, err := service.RegisterUser(t.Context(), input)
+	if err != nil {
+		t.Fatalf("RegisterUser again: %v", err)
+	}
+
+	if second.ID != first.ID {
+		t.Errorf("registering the same email created a new user %s, want %s", second.ID, first.ID)
+	}
+	if got := service.GetUserByID(t.Context(), first.ID).Name; got != "Mario R." {
+		t.Errorf("name = %q, want the updated name", got)
+	}
+}
+
+func TestValidateOTP(t *testing.T) {
+	service, repos, mailer := newTestUserService()
+
+	user, err := service.RegisterUser(t.Context(), testRegisterInput())
+	if err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+
+	input := schemas.OTPInput{Email: user.Email, OTP: "wrong", Weight: 70, Height: 175, Heartrate: 80, Bodytemp: 36.8}
+	if _, err := service.ValidateOTP(t.Context(), input); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("ValidateOTP with a wrong code = %v, want ErrInvalidOTP", err)
+	}
+
+	input.OTP = mailer.otp
+	session, err := service.ValidateOTP(t.Context(), input)
+	if err != nil {
+		t.Fatalf("ValidateOTP: %v", err)
+	}
+	if session.UserID != user.ID || session.Weight != 70 {
+		t.Errorf("session = %+v, want the user's session with the given vitals", session)
+	}
+	if _, err := repos.Sessions.FindByID(t.Context(), session.ID); err != nil {
+		t.Errorf("session was not stored: %v", err)
+	}
+
+	// the code can only be used once
+	if _, err := service.ValidateOTP(t.Context(), input); !errors.Is(err, ErrInvalidOTP) {
+		t.Errorf("ValidateOTP with a used code = %v, want ErrInvalidOTP", err)
+	}
+
+	input.Email = "unknown@example.com"
+	if _, err := service.ValidateOTP(t.Context(), input); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("ValidateOTP for an unknown email = %v, want ErrUserNotFound", err)
+	}
+}
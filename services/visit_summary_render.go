@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/go-pdf/fpdf"
+)
+
+// summarySection is a titled block of the visit summary, shared by the PDF and the HTML rendering
+type summarySection struct {
+	Title string
+	Lines []string
+}
+
+// visitSummarySections lays out the content of the visit summary, empty parts read "-"
+func visitSummarySections(summary *schemas.VisitSummary) []summarySection {
+	doctor := "-"
+	if summary.Doctor != nil {
+		doctor = fmt.Sprintf("%s (%s), room %s", summary.Doctor.Name, summary.Doctor.Specialty, summary.Doctor.Roomno)
+	}
+
+	var symptoms []string
+	for _, symptom := range summary.Symptoms {
+		symptoms = append(symptoms, formatSymptoms([]models.SessionSymptom{symptom}))
+	}
+
+	var diagnoses []string
+	for _, diagnosis := range summary.Diagnoses {
+		line := diagnosis.Description
+		if diagnosis.ICD10Code != "" {
+			line = fmt.Sprintf("%s (%s)", line, diagnosis.ICD10Code)
+		}
+		diagnoses = append(diagnoses, line)
+	}
+	if len(diagnoses) == 0 && summary.DoctorDiagnosis != "" {
+		diagnoses = append(diagnoses, summary.DoctorDiagnosis)
+	}
+
+	var prescriptions []string
+	for _, prescription := range summary.Prescriptions {
+		line := fmt.Sprintf("%s: %s %s, %s for %d days",
+			prescription.Drug.Name, prescription.Dose, prescription.Route, prescription.Frequency, prescription.DurationDays)
+		if prescription.Notes != "" {
+			line += ". " + prescription.Notes
+		}
+		prescriptions = append(prescriptions, line)
+	}
+
+	var allergies []string
+	for _, allergy := range summary.Allergies {
+		allergies = append(allergies, allergy.Substance)
+	}
+
+	return []summarySection{
+		{Title: "Patient", Lines: []string{
+			"Name: " + summary.Patient.Name,
+			"Age: " + summary.Patient.Age,
+			"Gender: " + summary.Patient.Gender,
+			"Nationality: " + summary.Patient.Nationality,
+			"Allergies: " + orDash(strings.Join(allergies, ", ")),
+		}},
+		{Title: "Doctor", Lines: []string{doctor}},
+		{Title: "Vitals", Lines: []string{
+			fmt.Sprintf("Weight: %.1f kg", summary.Vitals.Weight),
+			fmt.Sprintf("Height: %.1f cm", summary.Vitals.Height),
+			fmt.Sprintf("Heart rate: %.0f bpm", summary.Vitals.Heartrate),
+			fmt.Sprintf("Body temperature: %.1f °C", summary.Vitals.Bodytemp),
+		}},
+		{Title: "Symptoms", Lines: orDashLines(symptoms)},
+		{Title: "Prediagnosis", Lines: []string{orDash(summary.Prediagnosis)}},
+		{Title: "Diagnosis", Lines: orDashLines(diagnoses)},
+		{Title: "Prescriptions", Lines: orDashLines(prescriptions)},
+		{Title: "Follow-up instructions", Lines: orDashLines(strings.Split(strings.TrimSpace(summary.FollowUp), "\n"))},
+	}
+}
+
+func orDash(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "-"
+	}
+	return text
+}
+
+func orDashLines(lines []string) []string {
+	var filtered []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			filtered = append(filtered, line)
+		}
+	}
+	if len(filtered) == 0 {
+		return []string{"-"}
+	}
+	return filtered
+}
+
+// RenderVisitSummaryPDF renders the visit summary as an A4 PDF
+func RenderVisitSummaryPDF(summary *schemas.VisitSummary) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle("Visit Summary", true)
+	pdf.SetAuthor("Triana", true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	// the core fonts are cp1252, translate the UTF-8 text (e.g. "°C" or accented names)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, "Visit Summary", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(120, 120, 120)
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("Visit date: %s", summary.VisitDate.Format("2 January 2006 15:04"))), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	for _, section := range visitSummarySections(summary) {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, tr(section.Title), "B", 1, "L", false, 0, "")
+		pdf.Ln(1)
+		pdf.SetFont("Helvetica", "", 11)
+		for _, line := range section.Lines {
+			pdf.MultiCell(0, 6, tr(line), "", "L", false)
+		}
+		pdf.Ln(4)
+	}
+
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.SetTextColor(120, 120, 120)
+	pdf.MultiCell(0, 4, "This summary was generated by Triana. The prediagnosis is an AI suggestion, follow the diagnosis and instructions of your doctor.", "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render visit summary PDF: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderVisitSummaryHTML renders the visit summary with the email template
+func RenderVisitSummaryHTML(summary *schemas.VisitSummary) string {
+	var sections strings.Builder
+	for _, section := range visitSummarySections(summary) {
+		sections.WriteString("<h3>" + html.EscapeString(section.Title) + "</h3>\n<ul>\n")
+		for _, line := range section.Lines {
+			sections.WriteString("<li>" + html.EscapeString(line) + "</li>\n")
+		}
+		sections.WriteString("</ul>\n")
+	}
+
+	htmlString := readEmailTemplate("emails/visit_summary_mail.html")
+	htmlString = strings.ReplaceAll(htmlString, "{{patient_name}}", html.EscapeString(summary.Patient.Name))
+	htmlString = strings.ReplaceAll(htmlString, "{{visit_date}}", summary.VisitDate.Format("2 January 2006 15:04"))
+	htmlString = strings.ReplaceAll(htmlString, "{{sections}}", sections.String())
+
+	return htmlString
+}
+
+// SendVisitSummaryEmail emails the visit summary as HTML with the PDF attached
+func SendVisitSummaryEmail(ctx context.Context, to string, summary *schemas.VisitSummary, token string) (map[string]interface{}, error) {
+	pdf, err := RenderVisitSummaryPDF(summary)
+	if err != nil {
+		return nil, err
+	}
+
+	email := schemas.Email{
+		To:      to,
+		Subject: "Your Visit Summary",
+		Body:    "Your visit summary is attached",
+		From:    "triana@ai.com",
+		HTML:    RenderVisitSummaryHTML(summary),
+		Attachments: []schemas.EmailAttachment{{
+			Filename:    VisitSummaryFilename(summary),
+			Content:     base64.StdEncoding.EncodeToString(pdf),
+			ContentType: "application/pdf",
+		}},
+	}
+
+	return sendEmail(ctx, "visit_summary", email, token)
+}
+
+// VisitSummaryFilename is the name of the PDF file of a visit summary
+func VisitSummaryFilename(summary *schemas.VisitSummary) string {
+	return fmt.Sprintf("visit-summary-%s.pdf", summary.VisitDate.Format("2006-01-02"))
+}
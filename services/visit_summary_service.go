@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"github.com/BeeCodingAI/triana-api/models"
+	"github.com/BeeCodingAI/triana-api/schemas"
+	"github.com/BeeCodingAI/triana-api/utils"
+)
+
+// GetVisitSummary gathers the take-home record of a session
+func (s *SessionService) GetVisitSummary(ctx context.Context, sessionID string) (*schemas.VisitSummary, error) {
+	session, err := s.GetSessionData(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	prescriptions, err := GetPrescriptions(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := schemas.VisitSummary{
+		SessionID: session.ID.String(),
+		VisitDate: session.CreatedAt,
+		Patient: schemas.VisitPatient{
+			Name:        session.User.Name,
+			Age:         utils.DateToAgeString(session.User.DOB),
+			Gender:      session.User.Gender,
+			Nationality: session.User.Nationality,
+		},
+		Vitals: schemas.VisitVitals{
+			Weight:    session.Weight,
+			Height:    session.Height,
+			Heartrate: session.Heartrate,
+			Bodytemp:  session.Bodytemp,
+		},
+		Symptoms:        session.Symptoms,
+		Doctor:          s.treatingDoctor(ctx, session),
+		Prediagnosis:    session.Prediagnosis,
+		DoctorDiagnosis: session.DoctorDiagnosis,
+		Diagnoses:       []models.ConsultationDiagnosis{},
+		Prescriptions:   prescriptions,
+		Allergies:       GetPatientAllergies(session.UserID),
+	}
+
+	if summary.Symptoms == nil {
+		summary.Symptoms = []models.SessionSymptom{}
+	}
+
+	if session.ConsultationNote != nil {
+		summary.Diagnoses = session.ConsultationNote.Diagnoses
+		summary.FollowUp = session.ConsultationNote.Plan
+	}
+
+	return &summary, nil
+}
+
+// treatingDoctor is the author of the consultation note, or else the doctor the patient was last queued for
+func (s *SessionService) treatingDoctor(ctx context.Context, session models.Session) *models.Doctor {
+	if session.ConsultationNote != nil {
+		return &session.ConsultationNote.Doctor
+	}
+
+	if queue, err := s.queues.FindLatestBySession(ctx, session.ID); err == nil {
+		return s.doctors.GetDoctorByID(ctx, queue.DoctorID.String())
+	}
+
+	return routedDoctor(ctx, session.ID)
+}
@@ -0,0 +1,14 @@
+package services
+
+import "github.com/BeeCodingAI/triana-api/models"
+
+// vitalSigns are the vitals of a session with their LOINC code and UCUM unit, used by the FHIR and HL7 exports
+var vitalSigns = []struct {
+	key, loinc, display, unit string
+	value                     func(session *models.Session) float32
+}{
+	{"weight", "29463-7", "Body weight", "kg", func(s *models.Session) float32 { return s.Weight }},
+	{"height", "8302-2", "Body height", "cm", func(s *models.Session) float32 { return s.Height }},
+	{"heartrate", "8867-4", "Heart rate", "/min", func(s *models.Session) float32 { return s.Heartrate }},
+	{"bodytemp", "8310-5", "Body temperature", "Cel", func(s *models.Session) float32 { return s.Bodytemp }},
+}
@@ -2,7 +2,7 @@ package utils
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 )
 
@@ -15,7 +15,7 @@ func DateToAgeString(dateOfBirth string) string {
 	dob, err := time.Parse("2006-01-02", dateOfBirth)
 	if err != nil {
 		// log error
-		log.Printf("Error parsing date of birth: %v\n", err)
+		slog.Warn("Invalid date of birth", "dob", dateOfBirth)
 		return ""
 	}
 
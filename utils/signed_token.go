@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// signingSecret signs the links in emails, main sets it with SetSigningSecret
+var signingSecret string
+
+// SetSigningSecret sets the secret of the signed links, tokens are rejected while it is empty
+func SetSigningSecret(secret string) {
+	signingSecret = secret
+}
+
+// SignToken signs an action on a resource ID until expiresAt, used for links in emails that don't require a login.
+// The token is the expiry in Unix seconds and the signature, e.g. 1747440000.<signature>
+func SignToken(action string, id string, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expiry + "." + sign(action, id, expiry)
+}
+
+// VerifyToken checks a token created by SignToken and that it has not expired, it always fails when no secret is configured
+func VerifyToken(action string, id string, token string) bool {
+	if signingSecret == "" || token == "" {
+		return false
+	}
+
+	expiry, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(expiresAt, 0)) {
+		return false
+	}
+
+	return hmac.Equal([]byte(sign(action, id, expiry)), []byte(signature))
+}
+
+// sign covers the expiry too, so it cannot be extended without the secret
+func sign(action string, id string, expiry string) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(action + ":" + id + ":" + expiry))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
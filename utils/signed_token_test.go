@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyToken(t *testing.T) {
+	SetSigningSecret("test-secret")
+	t.Cleanup(func() { SetSigningSecret("") })
+
+	valid := SignToken("queue/cancel", "queue-1", time.Now().Add(time.Hour))
+	expiry, signature, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name   string
+		action string
+		id     string
+		token  string
+		want   bool
+	}{
+		{"valid", "queue/cancel", "queue-1", valid, true},
+		{"other action", "queue/check-in", "queue-1", valid, false},
+		{"other ID", "queue/cancel", "queue-2", valid, false},
+		{"tampered signature", "queue/cancel", "queue-1", expiry + "." + strings.ToUpper(signature), false},
+		{"extended expiry", "queue/cancel", "queue-1", "9999999999." + signature, false},
+		{"expired", "queue/cancel", "queue-1", SignToken("queue/cancel", "queue-1", time.Now().Add(-time.Minute)), false},
+		{"without expiry", "queue/cancel", "queue-1", signature, false},
+		{"empty", "queue/cancel", "queue-1", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := VerifyToken(tt.action, tt.id, tt.token); got != tt.want {
+			t.Errorf("%s: VerifyToken = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTokenWithoutSecret(t *testing.T) {
+	SetSigningSecret("")
+	token := SignToken("queue/cancel", "queue-1", time.Now().Add(time.Hour))
+	if VerifyToken("queue/cancel", "queue-1", token) {
+		t.Error("token verified without a signing secret")
+	}
+}